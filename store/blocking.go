@@ -0,0 +1,182 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//timeoutSeconds converts the timeout of a blocking command to seconds. Redis counts it in milliseconds, so it is
+//rounded up to a millisecond so the command doesn't return early or turn a tiny timeout into zero, which blocks until
+//an item is available.
+func timeoutSeconds(timeout time.Duration) float64 {
+	ms := timeout / time.Millisecond
+	if timeout%time.Millisecond != 0 {
+		ms++
+	}
+	return float64(ms) / 1000
+}
+
+//BlockingPop pops an item from the front or the end of the first non empty list of the keys, waiting up to timeout
+//for an item to be pushed when they are all empty. It returns the key the item was popped from and the item.
+//ErrNotFound is returned when the timeout expires and a zero timeout waits forever. The Redis store runs the command on
+//a connection from a separate pool, so blocked callers don't hold the connections of other commands. Its timeout is
+//sent in fractional seconds, which needs Redis 6 or later.
+func (r *Redis) BlockingPop(keys []string, timeout time.Duration, atEnd bool) (string, string, error) {
+	return r.BlockingPopContext(context.Background(), keys, timeout, atEnd)
+}
+
+//BlockingPopContext pops an item from the first non empty list of the keys, waiting until the timeout expires or the
+//context is done.
+func (r *Redis) BlockingPopContext(ctx context.Context, keys []string, timeout time.Duration, atEnd bool) (string, string, error) {
+	if len(keys) == 0 {
+		return "", "", opError("BlockingPop", "", ErrNoKeys)
+	}
+	if timeout < 0 {
+		return "", "", opError("BlockingPop", "", ErrNegativeTimeout)
+	}
+	cmd := "BLPOP"
+	if atEnd {
+		cmd = "BRPOP"
+	}
+	args := append(redis.Args{}.AddFlat(keys), timeoutSeconds(timeout))
+
+	vals, err := redis.Strings(r.doBlocking(ctx, cmd, args...))
+	if err != nil {
+		return "", "", redisError("BlockingPop", "", err)
+	}
+	return vals[0], vals[1], nil
+}
+
+//BlockingMove pops an item from the end of the src list and pushes it to the front of the dst list, waiting up to
+//timeout for an item to be pushed to src when it is empty. It returns the moved item. ErrNotFound is returned when the
+//timeout expires and a zero timeout waits forever.
+func (r *Redis) BlockingMove(src, dst string, timeout time.Duration) (string, error) {
+	return r.BlockingMoveContext(context.Background(), src, dst, timeout)
+}
+
+//BlockingMoveContext moves an item from the end of the src list to the front of the dst list, waiting until the
+//timeout expires or the context is done.
+func (r *Redis) BlockingMoveContext(ctx context.Context, src, dst string, timeout time.Duration) (string, error) {
+	if timeout < 0 {
+		return "", opError("BlockingMove", src, ErrNegativeTimeout)
+	}
+	v, err := redis.String(r.doBlocking(ctx, "BRPOPLPUSH", src, dst, timeoutSeconds(timeout)))
+	return v, redisError("BlockingMove", src, err)
+}
+
+//doBlocking runs a blocking command on a connection from the blocking pool, aborting it when the context is done.
+func (r *Redis) doBlocking(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	conn, err := poolConn(ctx, r.blocking)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.Do(cmd, append(args, contextArg{ctx})...)
+}
+
+//listSignal wakes the callers blocked on empty lists when items are pushed. It is guarded by the lock of the store.
+type listSignal struct {
+	ch chan struct{}
+}
+
+//newListSignal returns a signal with no waiters.
+func newListSignal() *listSignal {
+	return &listSignal{ch: make(chan struct{})}
+}
+
+//wait returns a channel that is closed on the next broadcast.
+func (s *listSignal) wait() <-chan struct{} {
+	return s.ch
+}
+
+//broadcast wakes all the waiters.
+func (s *listSignal) broadcast() {
+	close(s.ch)
+	s.ch = make(chan struct{})
+}
+
+//blockingPop runs pop until it finds an item or the timeout expires, waiting for pushes in between. A zero timeout
+//waits forever.
+func (m *MemoryStore) blockingPop(timeout time.Duration, pop func() (bool, error)) error {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		m.mu.Lock()
+		ok, err := pop()
+		pushed := m.pushed.wait()
+		m.mu.Unlock()
+		if ok || err != nil {
+			return err
+		}
+
+		select {
+		case <-pushed:
+		case <-expired:
+			return ErrNotFound
+		}
+	}
+}
+
+//BlockingPop pops an item from the front or the end of the first non empty list of the keys, waiting up to timeout
+//for an item to be pushed when they are all empty. It returns the key the item was popped from and the item.
+//ErrNotFound is returned when the timeout expires and a zero timeout waits forever.
+func (m *MemoryStore) BlockingPop(keys []string, timeout time.Duration, atEnd bool) (string, string, error) {
+	if len(keys) == 0 {
+		return "", "", opError("BlockingPop", "", ErrNoKeys)
+	}
+	if timeout < 0 {
+		return "", "", opError("BlockingPop", "", ErrNegativeTimeout)
+	}
+	var key, item string
+	err := m.blockingPop(timeout, func() (bool, error) {
+		for _, k := range keys {
+			l, ok, err := m.listValue(k)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				continue
+			}
+			if atEnd {
+				item, l = l[len(l)-1], l[:len(l)-1]
+			} else {
+				item, l = l[0], l[1:]
+			}
+			m.storeList(k, l)
+			key = k
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", "", opError("BlockingPop", "", err)
+	}
+	return key, item, nil
+}
+
+//BlockingMove pops an item from the end of the src list and pushes it to the front of the dst list, waiting up to
+//timeout for an item to be pushed to src when it is empty. It returns the moved item. ErrNotFound is returned when the
+//timeout expires and a zero timeout waits forever.
+func (m *MemoryStore) BlockingMove(src, dst string, timeout time.Duration) (string, error) {
+	if timeout < 0 {
+		return "", opError("BlockingMove", src, ErrNegativeTimeout)
+	}
+	var item string
+	err := m.blockingPop(timeout, func() (bool, error) {
+		var ok bool
+		var err error
+		item, ok, err = m.moveItem(src, dst)
+		return ok, err
+	})
+	if err != nil {
+		return "", opError("BlockingMove", src, err)
+	}
+	return item, nil
+}
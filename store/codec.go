@@ -0,0 +1,143 @@
+package store
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+)
+
+//Codec encodes values to bytes before they are stored and decodes them when they are read back.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+//JSONCodec encodes values as JSON.
+type JSONCodec struct{}
+
+//Marshal encodes v as JSON.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+//Unmarshal decodes the JSON data into v.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+//GobCodec encodes values with encoding/gob. Every value is encoded on its own, so types registered with gob.Register
+//are needed when storing interface values.
+type GobCodec struct{}
+
+//Marshal encodes v with gob.
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//Unmarshal decodes the gob data into v.
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+//ValueStore wraps a Store and encodes values with a Codec, so structs and other values can be round tripped through
+//any Store. The methods of the wrapped Store remain available.
+type ValueStore struct {
+	Store
+	codec Codec
+}
+
+//NewValueStore returns a ValueStore that encodes values stored in s with codec.
+func NewValueStore(s Store, codec Codec) *ValueStore {
+	return &ValueStore{
+		Store: s,
+		codec: codec,
+	}
+}
+
+//encode encodes value with the codec, wrapping the error with the operation and key.
+func (s *ValueStore) encode(op, key string, value interface{}) ([]byte, error) {
+	data, err := s.codec.Marshal(value)
+	return data, opError(op, key, err)
+}
+
+//SetValue encodes value and stores it at key.
+func (s *ValueStore) SetValue(key string, value interface{}) error {
+	data, err := s.encode("SetValue", key, value)
+	if err != nil {
+		return err
+	}
+	return s.Set(key, data)
+}
+
+//GetValue decodes the value stored at key into dst.
+func (s *ValueStore) GetValue(key string, dst interface{}) error {
+	data, err := s.GetString(key)
+	if err != nil {
+		return err
+	}
+	return opError("GetValue", key, s.codec.Unmarshal([]byte(data), dst))
+}
+
+//SetHashValue encodes value and stores it in the hash field of key.
+func (s *ValueStore) SetHashValue(key string, hash string, value interface{}) error {
+	data, err := s.encode("SetHashValue", key, value)
+	if err != nil {
+		return err
+	}
+	return s.SetHash(key, hash, data)
+}
+
+//GetHashValue decodes the value stored in the hash field of key into dst.
+func (s *ValueStore) GetHashValue(key string, hash string, dst interface{}) error {
+	data, err := s.GetHashString(key, hash)
+	if err != nil {
+		return err
+	}
+	return opError("GetHashValue", key, s.codec.Unmarshal([]byte(data), dst))
+}
+
+//PushValueToList encodes value and pushes it to the front or the end of the list.
+func (s *ValueStore) PushValueToList(key string, value interface{}, atEnd bool) error {
+	data, err := s.encode("PushValueToList", key, value)
+	if err != nil {
+		return err
+	}
+	return s.PushItemToList(key, data, atEnd)
+}
+
+//PopValueFromList pops an item from the front or the end of the list and decodes it into dst.
+func (s *ValueStore) PopValueFromList(key string, atEnd bool, dst interface{}) error {
+	item, err := s.PopItemFromList(key, DataTypeString, atEnd)
+	if err != nil {
+		return err
+	}
+	return opError("PopValueFromList", key, s.codec.Unmarshal([]byte(item.(string)), dst))
+}
+
+//ValuesFromList decodes the items of the list from start to end into dst, which must be a pointer to a slice.
+func (s *ValueStore) ValuesFromList(key string, start, end int, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return opError("ValuesFromList", key, ErrNotSlicePointer)
+	}
+
+	items, err := s.ItemsFromList(key, DataTypeString, start, end)
+	if err != nil {
+		return err
+	}
+
+	strs := items.([]string)
+	slice := reflect.MakeSlice(rv.Elem().Type(), len(strs), len(strs))
+	for i, item := range strs {
+		if err := s.codec.Unmarshal([]byte(item), slice.Index(i).Addr().Interface()); err != nil {
+			return opError("ValuesFromList", key, err)
+		}
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
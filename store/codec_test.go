@@ -0,0 +1,90 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/awkhan/go-store/store"
+	"github.com/stretchr/testify/assert"
+)
+
+type codecItem struct {
+	Name    string
+	Count   int
+	Tags    []string
+	Created time.Time
+}
+
+func newCodecItem(name string, count int) codecItem {
+	return codecItem{
+		Name:    name,
+		Count:   count,
+		Tags:    []string{"a", "b"},
+		Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func testValueStore(t *testing.T, vs *store.ValueStore) {
+	vs.ClearDataStore()
+
+	item := newCodecItem("value", 1)
+	assert.Nil(t, vs.SetValue("key", item), "Error setting value")
+
+	var v codecItem
+	err := vs.GetValue("key", &v)
+	assert.Nil(t, err, "Error getting value %v", err)
+	assert.Equal(t, item, v, "Invalid fetched value")
+
+	err = vs.GetValue("missing", &v)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing value should not be found")
+
+	assert.Nil(t, vs.SetHashValue("hkey", "field", item), "Error setting hash value")
+
+	var hv codecItem
+	err = vs.GetHashValue("hkey", "field", &hv)
+	assert.Nil(t, err, "Error getting hash value %v", err)
+	assert.Equal(t, item, hv, "Invalid fetched hash value")
+
+	items := []codecItem{newCodecItem("a", 1), newCodecItem("b", 2), newCodecItem("c", 3)}
+	for _, i := range items {
+		assert.Nil(t, vs.PushValueToList("lkey", i, true), "Error pushing value to list")
+	}
+
+	var lv []codecItem
+	err = vs.ValuesFromList("lkey", 0, -1, &lv)
+	assert.Nil(t, err, "Error getting values from list %v", err)
+	assert.Equal(t, items, lv, "Invalid fetched list values")
+
+	var pv codecItem
+	err = vs.PopValueFromList("lkey", true, &pv)
+	assert.Nil(t, err, "Error popping value from list %v", err)
+	assert.Equal(t, items[2], pv, "Invalid popped value")
+
+	err = vs.ValuesFromList("lkey", 0, -1, lv)
+	assert.True(t, errors.Is(err, store.ErrNotSlicePointer), "Values should only be decoded into a pointer to a slice")
+}
+
+func TestValueStoreJSON(t *testing.T) {
+	testValueStore(t, store.NewValueStore(store.NewMemoryStore(), store.JSONCodec{}))
+	testValueStore(t, store.NewValueStore(rs, store.JSONCodec{}))
+}
+
+func TestValueStoreGob(t *testing.T) {
+	testValueStore(t, store.NewValueStore(store.NewMemoryStore(), store.GobCodec{}))
+	testValueStore(t, store.NewValueStore(rs, store.GobCodec{}))
+}
+
+func TestValueStoreDecodeError(t *testing.T) {
+	vs := store.NewValueStore(store.NewMemoryStore(), store.JSONCodec{})
+	assert.Nil(t, vs.Set("key", "not json"))
+
+	var v codecItem
+	err := vs.GetValue("key", &v)
+
+	var opErr *store.OpError
+	assert.True(t, errors.As(err, &opErr), "Decode errors should be wrapped in an OpError")
+	if opErr != nil {
+		assert.Equal(t, "GetValue", opErr.Op, "Invalid operation")
+	}
+}
@@ -0,0 +1,621 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//commandStore holds the operations queued commands run. Every Store implements it, as does lockedMemoryStore, which
+//runs the commands of a memory store transaction while the lock of the store is held.
+type commandStore interface {
+	DeleteKey(keys ...string) (int, error)
+	GetString(key string) (string, error)
+	GetInt64(key string) (int64, error)
+	Set(key string, value interface{}) error
+	SetHash(key string, hash string, value interface{}) error
+	DeleteHash(key string, hash string) error
+	GetHashString(key string, hash string) (string, error)
+	SetExpiry(key string, seconds int) error
+	Increment(key string) error
+	Decrement(key string) error
+	SetAdd(key string, value interface{}) error
+	SetRemove(key string, value interface{}) error
+	SetIsMember(key string, value interface{}) (bool, error)
+	PushItemToList(key string, value interface{}, atEnd bool) error
+	RemoveItemFromList(key string, count int, value interface{}) error
+	LengthOfList(key string) (int, error)
+	PopItemFromList(key string, dataType int, atEnd bool) (interface{}, error)
+	ItemsFromList(key string, dataType int, start, end int) (interface{}, error)
+	HMSet(key string, values map[string]interface{}) error
+	Expire(key string, ttl time.Duration) error
+	ExpireAt(key string, t time.Time) error
+	Persist(key string) error
+	SetWithTTL(key string, value interface{}, ttl time.Duration) error
+	IncrementBy(key string, n int64) (int64, error)
+	DecrementBy(key string, n int64) (int64, error)
+	IncrementByFloat(key string, n float64) (float64, error)
+	HashIncrementBy(key string, hash string, n int64) (int64, error)
+	ItemFromList(key string, dataType int, index int) (interface{}, error)
+	SetItemInList(key string, index int, value interface{}) error
+	TrimList(key string, start, end int) error
+	InsertItemInList(key string, pivot, value interface{}, before bool) (int, error)
+	MoveItemToList(src, dst string, dataType int) (interface{}, error)
+	SetUnionStore(dst string, keys ...string) (int, error)
+	SetIntersectStore(dst string, keys ...string) (int, error)
+	SetDiffStore(dst string, keys ...string) (int, error)
+	ZAdd(key string, opts ZAddOptions, members ...ZMember) (int, error)
+	ZIncrBy(key string, member string, n float64) (float64, error)
+	ZScore(key string, member string) (float64, error)
+	ZRem(key string, members ...string) (int, error)
+	HashExists(key string, hash string) (bool, error)
+	HashSetIfNotExists(key string, hash string, value interface{}) (bool, error)
+	HashDelete(key string, hashes ...string) (int, error)
+	Exists(keys ...string) (int, error)
+	Rename(key, newKey string) error
+	RenameIfNotExists(key, newKey string) (bool, error)
+	Copy(src, dst string, replace bool) (bool, error)
+}
+
+//command is a store operation that can be queued by pipelines and transactions. It holds both the redis command and a
+//function running the operation on any Store, so backends without native support can run it directly.
+type command struct {
+	op   string
+	key  string
+	name string
+	args []interface{}
+	//reply converts the redis reply to the result value.
+	reply func(reply interface{}, err error) (interface{}, error)
+	//run runs the operation on a store.
+	run func(s commandStore) (interface{}, error)
+}
+
+//noReply discards the reply of commands that don't return a value.
+func noReply(reply interface{}, err error) (interface{}, error) {
+	return nil, err
+}
+
+//stringReply converts the reply to a string.
+func stringReply(reply interface{}, err error) (interface{}, error) {
+	return redis.String(reply, err)
+}
+
+//int64Reply converts the reply to an int64.
+func int64Reply(reply interface{}, err error) (interface{}, error) {
+	return redis.Int64(reply, err)
+}
+
+//intReply converts the reply to an int.
+func intReply(reply interface{}, err error) (interface{}, error) {
+	return redis.Int(reply, err)
+}
+
+//boolReply converts the reply to a bool.
+func boolReply(reply interface{}, err error) (interface{}, error) {
+	return redis.Bool(reply, err)
+}
+
+//deleteKeyCommand returns the command deleting the keys.
+func deleteKeyCommand(keys []string) command {
+	return command{
+		op: "DeleteKey", key: opKey(keys), name: "DEL", args: redis.Args{}.AddFlat(keys), reply: intReply,
+		run: func(s commandStore) (interface{}, error) {
+			return s.DeleteKey(keys...)
+		},
+	}
+}
+
+//getStringCommand returns the command fetching the string stored at key.
+func getStringCommand(key string) command {
+	return command{
+		op: "GetString", key: key, name: "GET", args: []interface{}{key}, reply: stringReply,
+		run: func(s commandStore) (interface{}, error) {
+			return s.GetString(key)
+		},
+	}
+}
+
+//getInt64Command returns the command fetching the int64 stored at key.
+func getInt64Command(key string) command {
+	return command{
+		op: "GetInt64", key: key, name: "GET", args: []interface{}{key}, reply: int64Reply,
+		run: func(s commandStore) (interface{}, error) {
+			return s.GetInt64(key)
+		},
+	}
+}
+
+//setCommand returns the command setting the value for the key.
+func setCommand(key string, value interface{}) command {
+	return command{
+		op: "Set", key: key, name: "SET", args: []interface{}{key, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.Set(key, value)
+		},
+	}
+}
+
+//setHashCommand returns the command setting the value of the hash key.
+func setHashCommand(key string, hash string, value interface{}) command {
+	return command{
+		op: "SetHash", key: key, name: "HSET", args: []interface{}{key, hash, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.SetHash(key, hash, value)
+		},
+	}
+}
+
+//deleteHashCommand returns the command deleting the hash key.
+func deleteHashCommand(key string, hash string) command {
+	return command{
+		op: "DeleteHash", key: key, name: "HDEL", args: []interface{}{key, hash}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.DeleteHash(key, hash)
+		},
+	}
+}
+
+//getHashStringCommand returns the command fetching the string value of the hash key.
+func getHashStringCommand(key string, hash string) command {
+	return command{
+		op: "GetHashString", key: key, name: "HGET", args: []interface{}{key, hash}, reply: stringReply,
+		run: func(s commandStore) (interface{}, error) {
+			return s.GetHashString(key, hash)
+		},
+	}
+}
+
+//setExpiryCommand returns the command setting the expiry of the key.
+func setExpiryCommand(key string, seconds int) command {
+	return command{
+		op: "SetExpiry", key: key, name: "EXPIRE", args: []interface{}{key, seconds}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.SetExpiry(key, seconds)
+		},
+	}
+}
+
+//incrementCommand returns the command incrementing the value of key by 1.
+func incrementCommand(key string) command {
+	return command{
+		op: "Increment", key: key, name: "INCR", args: []interface{}{key}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.Increment(key)
+		},
+	}
+}
+
+//decrementCommand returns the command decrementing the value of key by 1.
+func decrementCommand(key string) command {
+	return command{
+		op: "Decrement", key: key, name: "DECR", args: []interface{}{key}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.Decrement(key)
+		},
+	}
+}
+
+//setAddCommand returns the command adding the value to a set.
+func setAddCommand(key string, value interface{}) command {
+	return command{
+		op: "SetAdd", key: key, name: "SADD", args: []interface{}{key, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.SetAdd(key, value)
+		},
+	}
+}
+
+//setRemoveCommand returns the command removing the value from a set.
+func setRemoveCommand(key string, value interface{}) command {
+	return command{
+		op: "SetRemove", key: key, name: "SREM", args: []interface{}{key, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.SetRemove(key, value)
+		},
+	}
+}
+
+//setIsMemberCommand returns the command checking if the value is a member of the set.
+func setIsMemberCommand(key string, value interface{}) command {
+	return command{
+		op: "SetIsMember", key: key, name: "SISMEMBER", args: []interface{}{key, value}, reply: boolReply,
+		run: func(s commandStore) (interface{}, error) {
+			return s.SetIsMember(key, value)
+		},
+	}
+}
+
+//pushItemToListCommand returns the command pushing an item to the front or the end of the list.
+func pushItemToListCommand(key string, value interface{}, atEnd bool) command {
+	name := "LPUSH"
+	if atEnd {
+		name = "RPUSH"
+	}
+	return command{
+		op: "PushItemToList", key: key, name: name, args: []interface{}{key, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.PushItemToList(key, value, atEnd)
+		},
+	}
+}
+
+//removeItemFromListCommand returns the command removing count occurrences of the item from the list.
+func removeItemFromListCommand(key string, count int, value interface{}) command {
+	return command{
+		op: "RemoveItemFromList", key: key, name: "LREM", args: []interface{}{key, count, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.RemoveItemFromList(key, count, value)
+		},
+	}
+}
+
+//lengthOfListCommand returns the command fetching the length of the list.
+func lengthOfListCommand(key string) command {
+	return command{
+		op: "LengthOfList", key: key, name: "LLEN", args: []interface{}{key}, reply: intReply,
+		run: func(s commandStore) (interface{}, error) {
+			return s.LengthOfList(key)
+		},
+	}
+}
+
+//popItemFromListCommand returns the command popping an item from the front or the back of the list. Like the redis
+//command, popping from an empty list does nothing.
+func popItemFromListCommand(key string, atEnd bool) command {
+	name := "LPOP"
+	if atEnd {
+		name = "RPOP"
+	}
+	return command{
+		op: "PopItemFromList", key: key, name: name, args: []interface{}{key}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.PopItemFromList(key, DataTypeString, atEnd)
+			if errors.Is(err, ErrNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		},
+	}
+}
+
+//itemsFromListCommand returns the command fetching the items of the list from start to end converted to the data
+//type.
+func itemsFromListCommand(key string, dataType int, start, end int) command {
+	return command{
+		op: "ItemsFromList", key: key, name: "LRANGE", args: []interface{}{key, start, end},
+		reply: func(reply interface{}, err error) (interface{}, error) {
+			vals, err := redis.Strings(reply, err)
+			if err != nil {
+				return nil, err
+			}
+			return convertItems(vals, dataType)
+		},
+		run: func(s commandStore) (interface{}, error) {
+			return s.ItemsFromList(key, dataType, start, end)
+		},
+	}
+}
+
+//hmsetCommand returns the command setting the values of the hash keys.
+func hmsetCommand(key string, values map[string]interface{}) command {
+	return command{
+		op: "HMSet", key: key, name: "HMSET", args: redis.Args{key}.AddFlat(values), reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.HMSet(key, values)
+		},
+	}
+}
+
+//expireCommand returns the command setting the expiry of the key with millisecond precision.
+func expireCommand(key string, ttl time.Duration) command {
+	return command{
+		op: "Expire", key: key, name: "PEXPIRE", args: []interface{}{key, milliseconds(ttl)}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.Expire(key, ttl)
+		},
+	}
+}
+
+//expireAtCommand returns the command setting the key to expire at the time.
+func expireAtCommand(key string, t time.Time) command {
+	return command{
+		op: "ExpireAt", key: key, name: "PEXPIREAT", args: []interface{}{key, t.UnixNano() / int64(time.Millisecond)},
+		reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.ExpireAt(key, t)
+		},
+	}
+}
+
+//persistCommand returns the command removing the expiry of the key.
+func persistCommand(key string) command {
+	return command{
+		op: "Persist", key: key, name: "PERSIST", args: []interface{}{key}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.Persist(key)
+		},
+	}
+}
+
+//setWithTTLCommand returns the command setting the value for the key together with its expiry.
+func setWithTTLCommand(key string, value interface{}, ttl time.Duration) command {
+	return command{
+		op: "SetWithTTL", key: key, name: "SET", args: []interface{}{key, value, "PX", milliseconds(ttl)}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.SetWithTTL(key, value, ttl)
+		},
+	}
+}
+
+//incrementByCommand returns the command adding n to the integer stored at key.
+func incrementByCommand(key string, n int64) command {
+	return command{
+		op: "IncrementBy", key: key, name: "INCRBY", args: []interface{}{key, n}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.IncrementBy(key, n)
+			return nil, err
+		},
+	}
+}
+
+//decrementByCommand returns the command subtracting n from the integer stored at key.
+func decrementByCommand(key string, n int64) command {
+	return command{
+		op: "DecrementBy", key: key, name: "DECRBY", args: []interface{}{key, n}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.DecrementBy(key, n)
+			return nil, err
+		},
+	}
+}
+
+//incrementByFloatCommand returns the command adding n to the number stored at key.
+func incrementByFloatCommand(key string, n float64) command {
+	return command{
+		op: "IncrementByFloat", key: key, name: "INCRBYFLOAT", args: []interface{}{key, n}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.IncrementByFloat(key, n)
+			return nil, err
+		},
+	}
+}
+
+//hashIncrementByCommand returns the command adding n to the integer stored in the hash key.
+func hashIncrementByCommand(key string, hash string, n int64) command {
+	return command{
+		op: "HashIncrementBy", key: key, name: "HINCRBY", args: []interface{}{key, hash, n}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.HashIncrementBy(key, hash, n)
+			return nil, err
+		},
+	}
+}
+
+//itemFromListCommand returns the command fetching the item at the index of the list converted to the data type.
+func itemFromListCommand(key string, dataType int, index int) command {
+	return command{
+		op: "ItemFromList", key: key, name: "LINDEX", args: []interface{}{key, index},
+		reply: func(reply interface{}, err error) (interface{}, error) {
+			val, err := redis.String(reply, err)
+			if err != nil {
+				return nil, err
+			}
+			return convertItem(val, dataType)
+		},
+		run: func(s commandStore) (interface{}, error) {
+			return s.ItemFromList(key, dataType, index)
+		},
+	}
+}
+
+//setItemInListCommand returns the command replacing the item at the index of the list.
+func setItemInListCommand(key string, index int, value interface{}) command {
+	return command{
+		op: "SetItemInList", key: key, name: "LSET", args: []interface{}{key, index, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.SetItemInList(key, index, value)
+		},
+	}
+}
+
+//trimListCommand returns the command trimming the list to the items from start to end.
+func trimListCommand(key string, start, end int) command {
+	return command{
+		op: "TrimList", key: key, name: "LTRIM", args: []interface{}{key, start, end}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.TrimList(key, start, end)
+		},
+	}
+}
+
+//insertItemInListCommand returns the command inserting the value before or after the pivot. Like the redis command,
+//it does nothing when the list or the pivot doesn't exist.
+func insertItemInListCommand(key string, pivot, value interface{}, before bool) command {
+	where := "AFTER"
+	if before {
+		where = "BEFORE"
+	}
+	return command{
+		op: "InsertItemInList", key: key, name: "LINSERT", args: []interface{}{key, where, pivot, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.InsertItemInList(key, pivot, value, before)
+			if errors.Is(err, ErrNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		},
+	}
+}
+
+//moveItemToListCommand returns the command moving an item from the end of the src list to the front of the dst list.
+//Like the redis command, it does nothing when src is empty.
+func moveItemToListCommand(src, dst string) command {
+	return command{
+		op: "MoveItemToList", key: src, name: "RPOPLPUSH", args: []interface{}{src, dst}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.MoveItemToList(src, dst, DataTypeString)
+			if errors.Is(err, ErrNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		},
+	}
+}
+
+//setUnionStoreCommand returns the command storing the union of the sets in dst.
+func setUnionStoreCommand(dst string, keys []string) command {
+	return command{
+		op: "SetUnionStore", key: dst, name: "SUNIONSTORE", args: redis.Args{}.Add(dst).AddFlat(keys), reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.SetUnionStore(dst, keys...)
+			return nil, err
+		},
+	}
+}
+
+//setIntersectStoreCommand returns the command storing the intersection of the sets in dst.
+func setIntersectStoreCommand(dst string, keys []string) command {
+	return command{
+		op: "SetIntersectStore", key: dst, name: "SINTERSTORE", args: redis.Args{}.Add(dst).AddFlat(keys), reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.SetIntersectStore(dst, keys...)
+			return nil, err
+		},
+	}
+}
+
+//setDiffStoreCommand returns the command storing the difference of the sets in dst.
+func setDiffStoreCommand(dst string, keys []string) command {
+	return command{
+		op: "SetDiffStore", key: dst, name: "SDIFFSTORE", args: redis.Args{}.Add(dst).AddFlat(keys), reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.SetDiffStore(dst, keys...)
+			return nil, err
+		},
+	}
+}
+
+//zaddCommand returns the command adding the members to the sorted set subject to the options.
+func zaddCommand(key string, opts ZAddOptions, members []ZMember) command {
+	return command{
+		op: "ZAdd", key: key, name: "ZADD", args: zaddArgs(key, opts, members), reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.ZAdd(key, opts, members...)
+			return nil, err
+		},
+	}
+}
+
+//zincrByCommand returns the command adding n to the score of the member.
+func zincrByCommand(key string, member string, n float64) command {
+	return command{
+		op: "ZIncrBy", key: key, name: "ZINCRBY", args: []interface{}{key, n, member}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.ZIncrBy(key, member, n)
+			return nil, err
+		},
+	}
+}
+
+//zscoreCommand returns the command fetching the score of the member.
+func zscoreCommand(key string, member string) command {
+	return command{
+		op: "ZScore", key: key, name: "ZSCORE", args: []interface{}{key, member},
+		reply: func(reply interface{}, err error) (interface{}, error) {
+			return redis.Float64(reply, err)
+		},
+		run: func(s commandStore) (interface{}, error) {
+			return s.ZScore(key, member)
+		},
+	}
+}
+
+//zremCommand returns the command removing the members from the sorted set.
+func zremCommand(key string, members []string) command {
+	return command{
+		op: "ZRem", key: key, name: "ZREM", args: redis.Args{key}.AddFlat(members), reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.ZRem(key, members...)
+			return nil, err
+		},
+	}
+}
+
+//hashExistsCommand returns the command checking if the hash key exists.
+func hashExistsCommand(key string, hash string) command {
+	return command{
+		op: "HashExists", key: key, name: "HEXISTS", args: []interface{}{key, hash}, reply: boolReply,
+		run: func(s commandStore) (interface{}, error) {
+			return s.HashExists(key, hash)
+		},
+	}
+}
+
+//hashSetIfNotExistsCommand returns the command setting the value of the hash key only if it doesn't exist.
+func hashSetIfNotExistsCommand(key string, hash string, value interface{}) command {
+	return command{
+		op: "HashSetIfNotExists", key: key, name: "HSETNX", args: []interface{}{key, hash, value}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.HashSetIfNotExists(key, hash, value)
+			return nil, err
+		},
+	}
+}
+
+//hashDeleteCommand returns the command deleting the hash keys.
+func hashDeleteCommand(key string, hashes []string) command {
+	return command{
+		op: "HashDelete", key: key, name: "HDEL", args: redis.Args{key}.AddFlat(hashes), reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.HashDelete(key, hashes...)
+			return nil, err
+		},
+	}
+}
+
+//existsCommand returns the command counting the keys that exist.
+func existsCommand(keys []string) command {
+	return command{
+		op: "Exists", key: opKey(keys), name: "EXISTS", args: redis.Args{}.AddFlat(keys), reply: intReply,
+		run: func(s commandStore) (interface{}, error) {
+			return s.Exists(keys...)
+		},
+	}
+}
+
+//renameCommand returns the command renaming the key to newKey.
+func renameCommand(key, newKey string) command {
+	return command{
+		op: "Rename", key: key, name: "RENAME", args: []interface{}{key, newKey}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			return nil, s.Rename(key, newKey)
+		},
+	}
+}
+
+//renameIfNotExistsCommand returns the command renaming the key to newKey only if newKey doesn't exist.
+func renameIfNotExistsCommand(key, newKey string) command {
+	return command{
+		op: "RenameIfNotExists", key: key, name: "RENAMENX", args: []interface{}{key, newKey}, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.RenameIfNotExists(key, newKey)
+			return nil, err
+		},
+	}
+}
+
+//copyCommand returns the command copying the value stored at src to dst.
+func copyCommand(src, dst string, replace bool) command {
+	args := redis.Args{src, dst}
+	if replace {
+		args = args.Add("REPLACE")
+	}
+	return command{
+		op: "Copy", key: src, name: "COPY", args: args, reply: noReply,
+		run: func(s commandStore) (interface{}, error) {
+			_, err := s.Copy(src, dst, replace)
+			return nil, err
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//SetIfNotExists sets the value for the key only if the key doesn't exist. It returns true if the value was set, so
+//the first of several concurrent writers wins.
+func (r *Redis) SetIfNotExists(key string, value interface{}) (bool, error) {
+	return r.SetIfNotExistsContext(context.Background(), key, value)
+}
+
+//SetIfNotExistsContext sets the value for the key only if the key doesn't exist.
+func (r *Redis) SetIfNotExistsContext(ctx context.Context, key string, value interface{}) (bool, error) {
+	return r.setIf(ctx, "SetIfNotExists", key, value, "NX")
+}
+
+//SetIfExists sets the value for the key only if the key exists. It returns true if the value was set.
+func (r *Redis) SetIfExists(key string, value interface{}) (bool, error) {
+	return r.SetIfExistsContext(context.Background(), key, value)
+}
+
+//SetIfExistsContext sets the value for the key only if the key exists.
+func (r *Redis) SetIfExistsContext(ctx context.Context, key string, value interface{}) (bool, error) {
+	return r.setIf(ctx, "SetIfExists", key, value, "XX")
+}
+
+//setIf runs SET with the NX or XX condition. A nil reply means the condition didn't hold.
+func (r *Redis) setIf(ctx context.Context, op, key string, value interface{}, cond string) (bool, error) {
+	_, err := redis.String(r.do(ctx, "SET", key, value, cond))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, redisError(op, key, err)
+	}
+	return true, nil
+}
+
+//GetAndSet sets the value for the key and returns the string it replaced. The bool reports whether the key existed,
+//telling a missing key apart from one holding an empty string. The key is set either way.
+func (r *Redis) GetAndSet(key string, value interface{}) (string, bool, error) {
+	return r.GetAndSetContext(context.Background(), key, value)
+}
+
+//GetAndSetContext sets the value for the key and returns the string it replaced and whether the key existed.
+func (r *Redis) GetAndSetContext(ctx context.Context, key string, value interface{}) (string, bool, error) {
+	v, err := redis.String(r.do(ctx, "GETSET", key, value))
+	if err == redis.ErrNil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, redisError("GetAndSet", key, err)
+	}
+	return v, true, nil
+}
+
+//compareAndSwapScript sets KEYS[1] to ARGV[2] if it holds ARGV[1], returning 1 if it did.
+var compareAndSwapScript = NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+//CompareAndSwap sets the key to new only if it currently holds old, compared as strings. It returns true if the value
+//was swapped and false if the key holds a different value or doesn't exist. The comparison and the swap run in a
+//single Lua script, so no other client can change the key in between.
+func (r *Redis) CompareAndSwap(key string, old, new interface{}) (bool, error) {
+	return r.CompareAndSwapContext(context.Background(), key, old, new)
+}
+
+//CompareAndSwapContext sets the key to new only if it currently holds old.
+func (r *Redis) CompareAndSwapContext(ctx context.Context, key string, old, new interface{}) (bool, error) {
+	swapped, err := redis.Bool(r.EvalContext(ctx, compareAndSwapScript, []string{key}, old, new))
+	var opErr *OpError
+	if errors.As(err, &opErr) {
+		err = opErr.Err
+	}
+	if err != nil {
+		return false, opError("CompareAndSwap", key, err)
+	}
+	return swapped, nil
+}
+
+//SetIfNotExists sets the value for the key only if the key doesn't exist. It returns true if the value was set.
+func (m *MemoryStore) SetIfNotExists(key string, value interface{}) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lookup(key) != nil {
+		return false, nil
+	}
+	m.storeEntry(key, &memoryEntry{value: formatValue(value)})
+	return true, nil
+}
+
+//SetIfExists sets the value for the key only if the key exists. It returns true if the value was set.
+func (m *MemoryStore) SetIfExists(key string, value interface{}) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lookup(key) == nil {
+		return false, nil
+	}
+	m.storeEntry(key, &memoryEntry{value: formatValue(value)})
+	return true, nil
+}
+
+//GetAndSet sets the value for the key and returns the string it replaced. The bool reports whether the key existed,
+//telling a missing key apart from one holding an empty string. The key is set either way.
+func (m *MemoryStore) GetAndSet(key string, value interface{}) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok, err := m.stringValue(key)
+	if err != nil {
+		return "", false, opError("GetAndSet", key, err)
+	}
+	m.storeEntry(key, &memoryEntry{value: formatValue(value)})
+	return s, ok, nil
+}
+
+//CompareAndSwap sets the key to new only if it currently holds old, compared as strings. It returns true if the value
+//was swapped and false if the key holds a different value or doesn't exist.
+func (m *MemoryStore) CompareAndSwap(key string, old, new interface{}) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok, err := m.stringValue(key)
+	if err != nil {
+		return false, opError("CompareAndSwap", key, err)
+	}
+	if !ok || s != formatValue(old) {
+		return false, nil
+	}
+	m.storeEntry(key, &memoryEntry{value: formatValue(new)})
+	return true, nil
+}
@@ -0,0 +1,27 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/awkhan/go-store/store"
+	"github.com/awkhan/go-store/store/storetest"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	storetest.RunConformance(t, func() store.Store {
+		return store.NewMemoryStore()
+	})
+}
+
+func TestRedisConformance(t *testing.T) {
+	storetest.RunConformance(t, func() store.Store {
+		return rs
+	})
+}
+
+func TestContextAdapterConformance(t *testing.T) {
+	storetest.RunConformance(t, func() store.Store {
+		return store.NewContextAdapter(context.Background(), rs)
+	})
+}
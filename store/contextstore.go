@@ -0,0 +1,481 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+//contextAdapter implements Store on top of a ContextStore.
+type contextAdapter struct {
+	cs  ContextStore
+	ctx context.Context
+}
+
+//NewContextAdapter returns a Store that calls the ContextStore with the supplied context. Cancelling the context
+//aborts every operation of the returned store, so pass context.Background() for a store that is never cancelled.
+func NewContextAdapter(ctx context.Context, cs ContextStore) Store {
+	return &contextAdapter{
+		cs:  cs,
+		ctx: ctx,
+	}
+}
+
+//DeleteKey deletes the keys and returns the number of keys deleted.
+func (a *contextAdapter) DeleteKey(keys ...string) (int, error) {
+	return a.cs.DeleteKeyContext(a.ctx, keys...)
+}
+
+//Unlink deletes the keys and returns the number of keys deleted.
+func (a *contextAdapter) Unlink(keys ...string) (int, error) {
+	return a.cs.UnlinkContext(a.ctx, keys...)
+}
+
+//Exists returns the number of the keys that exist.
+func (a *contextAdapter) Exists(keys ...string) (int, error) {
+	return a.cs.ExistsContext(a.ctx, keys...)
+}
+
+//Touch updates the last access time of the keys and returns the number of keys that exist.
+func (a *contextAdapter) Touch(keys ...string) (int, error) {
+	return a.cs.TouchContext(a.ctx, keys...)
+}
+
+//Type returns the type of the value stored at key.
+func (a *contextAdapter) Type(key string) (KeyType, error) {
+	return a.cs.TypeContext(a.ctx, key)
+}
+
+//Rename renames the key to newKey, replacing any value stored at newKey.
+func (a *contextAdapter) Rename(key, newKey string) error {
+	return a.cs.RenameContext(a.ctx, key, newKey)
+}
+
+//RenameIfNotExists renames the key to newKey only if newKey doesn't exist.
+func (a *contextAdapter) RenameIfNotExists(key, newKey string) (bool, error) {
+	return a.cs.RenameIfNotExistsContext(a.ctx, key, newKey)
+}
+
+//Copy copies the value stored at src to dst.
+func (a *contextAdapter) Copy(src, dst string, replace bool) (bool, error) {
+	return a.cs.CopyContext(a.ctx, src, dst, replace)
+}
+
+//GetString retrieves the string data stored at key.
+func (a *contextAdapter) GetString(key string) (string, error) {
+	return a.cs.GetStringContext(a.ctx, key)
+}
+
+//GetInt64 retrieves the int64 data stored at key.
+func (a *contextAdapter) GetInt64(key string) (int64, error) {
+	return a.cs.GetInt64Context(a.ctx, key)
+}
+
+//GetBytes retrieves the data stored at key as bytes.
+func (a *contextAdapter) GetBytes(key string) ([]byte, error) {
+	return a.cs.GetBytesContext(a.ctx, key)
+}
+
+//GetFloat64 retrieves the float64 data stored at key.
+func (a *contextAdapter) GetFloat64(key string) (float64, error) {
+	return a.cs.GetFloat64Context(a.ctx, key)
+}
+
+//GetBool retrieves the bool data stored at key.
+func (a *contextAdapter) GetBool(key string) (bool, error) {
+	return a.cs.GetBoolContext(a.ctx, key)
+}
+
+//GetTime retrieves the time stored at key.
+func (a *contextAdapter) GetTime(key string) (time.Time, error) {
+	return a.cs.GetTimeContext(a.ctx, key)
+}
+
+//Get scans the data stored at key into dst.
+func (a *contextAdapter) Get(key string, dst interface{}) error {
+	return a.cs.GetContext(a.ctx, key, dst)
+}
+
+//MGetStrings retrieves the string data stored at the keys. Missing keys are left out of the map.
+func (a *contextAdapter) MGetStrings(keys ...string) (map[string]string, error) {
+	return a.cs.MGetStringsContext(a.ctx, keys...)
+}
+
+//Set sets the value for the specified key.
+func (a *contextAdapter) Set(key string, value interface{}) error {
+	return a.cs.SetContext(a.ctx, key, value)
+}
+
+//MSet sets the values for the keys.
+func (a *contextAdapter) MSet(values map[string]interface{}) error {
+	return a.cs.MSetContext(a.ctx, values)
+}
+
+//SetIfNotExists sets the value for the key only if the key doesn't exist.
+func (a *contextAdapter) SetIfNotExists(key string, value interface{}) (bool, error) {
+	return a.cs.SetIfNotExistsContext(a.ctx, key, value)
+}
+
+//SetIfExists sets the value for the key only if the key exists.
+func (a *contextAdapter) SetIfExists(key string, value interface{}) (bool, error) {
+	return a.cs.SetIfExistsContext(a.ctx, key, value)
+}
+
+//GetAndSet sets the value for the key and returns the string it replaced and whether the key existed.
+func (a *contextAdapter) GetAndSet(key string, value interface{}) (string, bool, error) {
+	return a.cs.GetAndSetContext(a.ctx, key, value)
+}
+
+//CompareAndSwap sets the key to new only if it currently holds old.
+func (a *contextAdapter) CompareAndSwap(key string, old, new interface{}) (bool, error) {
+	return a.cs.CompareAndSwapContext(a.ctx, key, old, new)
+}
+
+//SetHash sets the value for the specific hash key.
+func (a *contextAdapter) SetHash(key string, hash string, value interface{}) error {
+	return a.cs.SetHashContext(a.ctx, key, hash, value)
+}
+
+//DeleteHash deletes the hash value for the specific key.
+func (a *contextAdapter) DeleteHash(key string, hash string) error {
+	return a.cs.DeleteHashContext(a.ctx, key, hash)
+}
+
+//GetHashString returns the string value of the hash.
+func (a *contextAdapter) GetHashString(key string, hash string) (string, error) {
+	return a.cs.GetHashStringContext(a.ctx, key, hash)
+}
+
+//GetHashInt64 returns the value of the hash key as an int64.
+func (a *contextAdapter) GetHashInt64(key string, hash string) (int64, error) {
+	return a.cs.GetHashInt64Context(a.ctx, key, hash)
+}
+
+//GetHashFloat64 returns the value of the hash key as a float64.
+func (a *contextAdapter) GetHashFloat64(key string, hash string) (float64, error) {
+	return a.cs.GetHashFloat64Context(a.ctx, key, hash)
+}
+
+//GetHashBool returns the value of the hash key as a bool.
+func (a *contextAdapter) GetHashBool(key string, hash string) (bool, error) {
+	return a.cs.GetHashBoolContext(a.ctx, key, hash)
+}
+
+//HashExists returns true if the hash key exists.
+func (a *contextAdapter) HashExists(key string, hash string) (bool, error) {
+	return a.cs.HashExistsContext(a.ctx, key, hash)
+}
+
+//HashLength returns the number of keys of the hash.
+func (a *contextAdapter) HashLength(key string) (int, error) {
+	return a.cs.HashLengthContext(a.ctx, key)
+}
+
+//HashSetIfNotExists sets the value of the hash key only if it doesn't exist.
+func (a *contextAdapter) HashSetIfNotExists(key string, hash string, value interface{}) (bool, error) {
+	return a.cs.HashSetIfNotExistsContext(a.ctx, key, hash, value)
+}
+
+//HashDelete deletes the hash keys and returns the number of keys deleted.
+func (a *contextAdapter) HashDelete(key string, hashes ...string) (int, error) {
+	return a.cs.HashDeleteContext(a.ctx, key, hashes...)
+}
+
+//GetAllHashValues returns all the hash values for the key.
+func (a *contextAdapter) GetAllHashValues(key string) ([]string, error) {
+	return a.cs.GetAllHashValuesContext(a.ctx, key)
+}
+
+//GetAllHashKeys returns all the hash keys for the key.
+func (a *contextAdapter) GetAllHashKeys(key string) ([]string, error) {
+	return a.cs.GetAllHashKeysContext(a.ctx, key)
+}
+
+//SetHashStruct stores the fields of the struct in the hash.
+func (a *contextAdapter) SetHashStruct(key string, v interface{}) error {
+	return a.cs.SetHashStructContext(a.ctx, key, v)
+}
+
+//GetHashStruct reads the hash into the fields of the struct.
+func (a *contextAdapter) GetHashStruct(key string, dst interface{}) error {
+	return a.cs.GetHashStructContext(a.ctx, key, dst)
+}
+
+//HMSet sets the values of the hash keys.
+func (a *contextAdapter) HMSet(key string, values map[string]interface{}) error {
+	return a.cs.HMSetContext(a.ctx, key, values)
+}
+
+//HMGet returns the string values of the hash keys. Missing hash keys are left out of the map.
+func (a *contextAdapter) HMGet(key string, hashes ...string) (map[string]string, error) {
+	return a.cs.HMGetContext(a.ctx, key, hashes...)
+}
+
+//HGetAll returns all the hash keys and values for the key.
+func (a *contextAdapter) HGetAll(key string) (map[string]string, error) {
+	return a.cs.HGetAllContext(a.ctx, key)
+}
+
+//SetExpiry sets the expiry for the specified key.
+func (a *contextAdapter) SetExpiry(key string, seconds int) error {
+	return a.cs.SetExpiryContext(a.ctx, key, seconds)
+}
+
+//Expire sets the expiry of the key with millisecond precision.
+func (a *contextAdapter) Expire(key string, ttl time.Duration) error {
+	return a.cs.ExpireContext(a.ctx, key, ttl)
+}
+
+//ExpireAt sets the key to expire at the time.
+func (a *contextAdapter) ExpireAt(key string, t time.Time) error {
+	return a.cs.ExpireAtContext(a.ctx, key, t)
+}
+
+//Persist removes the expiry of the key.
+func (a *contextAdapter) Persist(key string) error {
+	return a.cs.PersistContext(a.ctx, key)
+}
+
+//TTL returns the time left before the key expires.
+func (a *contextAdapter) TTL(key string) (time.Duration, error) {
+	return a.cs.TTLContext(a.ctx, key)
+}
+
+//SetWithTTL sets the value for the key together with its expiry.
+func (a *contextAdapter) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	return a.cs.SetWithTTLContext(a.ctx, key, value, ttl)
+}
+
+//Increment increments the value of key by 1.
+func (a *contextAdapter) Increment(key string) error {
+	return a.cs.IncrementContext(a.ctx, key)
+}
+
+//Decrement decrements the value of key by 1.
+func (a *contextAdapter) Decrement(key string) error {
+	return a.cs.DecrementContext(a.ctx, key)
+}
+
+//IncrementBy adds n to the integer stored at key and returns the new value.
+func (a *contextAdapter) IncrementBy(key string, n int64) (int64, error) {
+	return a.cs.IncrementByContext(a.ctx, key, n)
+}
+
+//DecrementBy subtracts n from the integer stored at key and returns the new value.
+func (a *contextAdapter) DecrementBy(key string, n int64) (int64, error) {
+	return a.cs.DecrementByContext(a.ctx, key, n)
+}
+
+//IncrementByFloat adds n to the number stored at key and returns the new value.
+func (a *contextAdapter) IncrementByFloat(key string, n float64) (float64, error) {
+	return a.cs.IncrementByFloatContext(a.ctx, key, n)
+}
+
+//HashIncrementBy adds n to the integer stored in the hash key and returns the new value.
+func (a *contextAdapter) HashIncrementBy(key string, hash string, n int64) (int64, error) {
+	return a.cs.HashIncrementByContext(a.ctx, key, hash, n)
+}
+
+//SetAdd adds a the value to a set.
+func (a *contextAdapter) SetAdd(key string, value interface{}) error {
+	return a.cs.SetAddContext(a.ctx, key, value)
+}
+
+//GetSetStringMembers returns the string members of a set.
+func (a *contextAdapter) GetSetStringMembers(key string) ([]string, error) {
+	return a.cs.GetSetStringMembersContext(a.ctx, key)
+}
+
+//SetRemove removes the value from the set.
+func (a *contextAdapter) SetRemove(key string, value interface{}) error {
+	return a.cs.SetRemoveContext(a.ctx, key, value)
+}
+
+//SetIsMember returns true if the value is a member of the set.
+func (a *contextAdapter) SetIsMember(key string, value interface{}) (bool, error) {
+	return a.cs.SetIsMemberContext(a.ctx, key, value)
+}
+
+//SetUnion returns the members of all the sets.
+func (a *contextAdapter) SetUnion(keys ...string) ([]string, error) {
+	return a.cs.SetUnionContext(a.ctx, keys...)
+}
+
+//SetIntersect returns the members found in every one of the sets.
+func (a *contextAdapter) SetIntersect(keys ...string) ([]string, error) {
+	return a.cs.SetIntersectContext(a.ctx, keys...)
+}
+
+//SetDiff returns the members of the first set that are not in any of the other sets.
+func (a *contextAdapter) SetDiff(keys ...string) ([]string, error) {
+	return a.cs.SetDiffContext(a.ctx, keys...)
+}
+
+//SetUnionStore stores the union of the sets in dst and returns the number of members stored.
+func (a *contextAdapter) SetUnionStore(dst string, keys ...string) (int, error) {
+	return a.cs.SetUnionStoreContext(a.ctx, dst, keys...)
+}
+
+//SetIntersectStore stores the intersection of the sets in dst and returns the number of members stored.
+func (a *contextAdapter) SetIntersectStore(dst string, keys ...string) (int, error) {
+	return a.cs.SetIntersectStoreContext(a.ctx, dst, keys...)
+}
+
+//SetDiffStore stores the difference of the sets in dst and returns the number of members stored.
+func (a *contextAdapter) SetDiffStore(dst string, keys ...string) (int, error) {
+	return a.cs.SetDiffStoreContext(a.ctx, dst, keys...)
+}
+
+//SetCardinality returns the number of members of the set.
+func (a *contextAdapter) SetCardinality(key string) (int, error) {
+	return a.cs.SetCardinalityContext(a.ctx, key)
+}
+
+//SetPop removes up to count random members from the set and returns them.
+func (a *contextAdapter) SetPop(key string, count int) ([]string, error) {
+	return a.cs.SetPopContext(a.ctx, key, count)
+}
+
+//SetRandomMembers returns up to count random members of the set without removing them.
+func (a *contextAdapter) SetRandomMembers(key string, count int) ([]string, error) {
+	return a.cs.SetRandomMembersContext(a.ctx, key, count)
+}
+
+//ZAdd adds the members to the sorted set or updates their scores and returns the number of members added.
+func (a *contextAdapter) ZAdd(key string, opts ZAddOptions, members ...ZMember) (int, error) {
+	return a.cs.ZAddContext(a.ctx, key, opts, members...)
+}
+
+//ZIncrBy adds n to the score of the member and returns the new score.
+func (a *contextAdapter) ZIncrBy(key string, member string, n float64) (float64, error) {
+	return a.cs.ZIncrByContext(a.ctx, key, member, n)
+}
+
+//ZScore returns the score of the member.
+func (a *contextAdapter) ZScore(key string, member string) (float64, error) {
+	return a.cs.ZScoreContext(a.ctx, key, member)
+}
+
+//ZRank returns the zero based rank of the member ordered from the lowest score.
+func (a *contextAdapter) ZRank(key string, member string) (int, error) {
+	return a.cs.ZRankContext(a.ctx, key, member)
+}
+
+//ZRevRank returns the zero based rank of the member ordered from the highest score.
+func (a *contextAdapter) ZRevRank(key string, member string) (int, error) {
+	return a.cs.ZRevRankContext(a.ctx, key, member)
+}
+
+//ZRangeByScore returns the members with a score in the range along with their scores.
+func (a *contextAdapter) ZRangeByScore(key string, by ZRangeBy) ([]ZMember, error) {
+	return a.cs.ZRangeByScoreContext(a.ctx, key, by)
+}
+
+//ZRangeByLex returns the members in the lex range.
+func (a *contextAdapter) ZRangeByLex(key string, by ZRangeBy) ([]string, error) {
+	return a.cs.ZRangeByLexContext(a.ctx, key, by)
+}
+
+//ZRem removes the members from the sorted set and returns the number of members removed.
+func (a *contextAdapter) ZRem(key string, members ...string) (int, error) {
+	return a.cs.ZRemContext(a.ctx, key, members...)
+}
+
+//ZRemRangeByScore removes the members with a score between min and max.
+func (a *contextAdapter) ZRemRangeByScore(key string, min, max string) (int, error) {
+	return a.cs.ZRemRangeByScoreContext(a.ctx, key, min, max)
+}
+
+//ZCard returns the number of members of the sorted set.
+func (a *contextAdapter) ZCard(key string) (int, error) {
+	return a.cs.ZCardContext(a.ctx, key)
+}
+
+//PushItemToList pushes an item to the front or the end of the list.
+func (a *contextAdapter) PushItemToList(key string, value interface{}, atEnd bool) error {
+	return a.cs.PushItemToListContext(a.ctx, key, value, atEnd)
+}
+
+//PopItemFromList pops an item from the front or the back of the list.
+func (a *contextAdapter) PopItemFromList(key string, dataType int, atEnd bool) (interface{}, error) {
+	return a.cs.PopItemFromListContext(a.ctx, key, dataType, atEnd)
+}
+
+//ItemsFromList returns a list of items from the list from the start to end.
+func (a *contextAdapter) ItemsFromList(key string, dataType int, start, end int) (interface{}, error) {
+	return a.cs.ItemsFromListContext(a.ctx, key, dataType, start, end)
+}
+
+//RemoveItemFromList removes the item from the list with the count occurances.
+func (a *contextAdapter) RemoveItemFromList(key string, count int, value interface{}) error {
+	return a.cs.RemoveItemFromListContext(a.ctx, key, count, value)
+}
+
+//LengthOfList returns the length of the list.
+func (a *contextAdapter) LengthOfList(key string) (int, error) {
+	return a.cs.LengthOfListContext(a.ctx, key)
+}
+
+//ItemFromList returns the item at the index of the list converted to the data type.
+func (a *contextAdapter) ItemFromList(key string, dataType int, index int) (interface{}, error) {
+	return a.cs.ItemFromListContext(a.ctx, key, dataType, index)
+}
+
+//SetItemInList replaces the item at the index of the list.
+func (a *contextAdapter) SetItemInList(key string, index int, value interface{}) error {
+	return a.cs.SetItemInListContext(a.ctx, key, index, value)
+}
+
+//TrimList trims the list so it only holds the items from start to end.
+func (a *contextAdapter) TrimList(key string, start, end int) error {
+	return a.cs.TrimListContext(a.ctx, key, start, end)
+}
+
+//InsertItemInList inserts the value before or after the first item of the list equal to pivot.
+func (a *contextAdapter) InsertItemInList(key string, pivot, value interface{}, before bool) (int, error) {
+	return a.cs.InsertItemInListContext(a.ctx, key, pivot, value, before)
+}
+
+//MoveItemToList moves an item from the end of the src list to the front of the dst list.
+func (a *contextAdapter) MoveItemToList(src, dst string, dataType int) (interface{}, error) {
+	return a.cs.MoveItemToListContext(a.ctx, src, dst, dataType)
+}
+
+//BlockingPop pops an item from the first non empty list of the keys, waiting up to timeout.
+func (a *contextAdapter) BlockingPop(keys []string, timeout time.Duration, atEnd bool) (string, string, error) {
+	return a.cs.BlockingPopContext(a.ctx, keys, timeout, atEnd)
+}
+
+//BlockingMove moves an item from the end of the src list to the front of the dst list, waiting up to timeout.
+func (a *contextAdapter) BlockingMove(src, dst string, timeout time.Duration) (string, error) {
+	return a.cs.BlockingMoveContext(a.ctx, src, dst, timeout)
+}
+
+//Transaction runs fn and applies the writes it queues atomically.
+func (a *contextAdapter) Transaction(watchKeys []string, fn func(tx Tx) error) error {
+	return a.cs.TransactionContext(a.ctx, watchKeys, fn)
+}
+
+//Scan returns an iterator over the keys matching the pattern.
+func (a *contextAdapter) Scan(pattern string, count int) *ScanIterator {
+	return a.cs.ScanContext(a.ctx, pattern, count)
+}
+
+//HScan returns an iterator over the hash keys and values of the hash.
+func (a *contextAdapter) HScan(key string, pattern string, count int) *ScanIterator {
+	return a.cs.HScanContext(a.ctx, key, pattern, count)
+}
+
+//SScan returns an iterator over the members of the set.
+func (a *contextAdapter) SScan(key string, pattern string, count int) *ScanIterator {
+	return a.cs.SScanContext(a.ctx, key, pattern, count)
+}
+
+//DeleteByPattern deletes the keys matching the pattern.
+func (a *contextAdapter) DeleteByPattern(pattern string) (int, error) {
+	return a.cs.DeleteByPatternContext(a.ctx, pattern)
+}
+
+//ClearDataStore clears up all the keys in the store.
+func (a *contextAdapter) ClearDataStore() error {
+	return a.cs.ClearDataStoreContext(a.ctx)
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+var (
+	//ErrNotFound is returned when the key, hash field or list item does not exist.
+	ErrNotFound = errors.New("not found")
+	//ErrWrongType is returned when an operation is run against a key holding a different kind of value.
+	ErrWrongType = errors.New("wrong type")
+	//ErrInvalidDataType is returned when an unsupported DataType constant is requested.
+	ErrInvalidDataType = errors.New("invalid data type")
+	//ErrNotInteger is returned when a counter operation is run against a value that is not an integer.
+	ErrNotInteger = errors.New("value is not an integer or out of range")
+	//ErrNotFloat is returned when a floating point counter operation is run against a value that is not a number.
+	ErrNotFloat = errors.New("value is not a valid float")
+	//ErrIndexOutOfRange is returned when setting a list item at an index past either end of the list.
+	ErrIndexOutOfRange = errors.New("index out of range")
+	//ErrNotSlicePointer is returned when ValuesFromList isn't given a pointer to a slice.
+	ErrNotSlicePointer = errors.New("destination must be a pointer to a slice")
+	//ErrNotStruct is returned when SetHashStruct isn't given a struct.
+	ErrNotStruct = errors.New("value must be a struct or a pointer to a struct")
+	//ErrNotStructPointer is returned when GetHashStruct isn't given a pointer to a struct.
+	ErrNotStructPointer = errors.New("destination must be a pointer to a struct")
+	//ErrTxConflict is returned when a transaction keeps failing because its watched keys are changed by other clients.
+	ErrTxConflict = errors.New("transaction conflict")
+	//ErrFlushLocked is returned when clearing a store created in production mode without unlocking it first.
+	ErrFlushLocked = errors.New("flush is locked in production mode")
+	//ErrEmptyPattern is returned when DeleteByPattern is given an empty pattern.
+	ErrEmptyPattern = errors.New("pattern must not be empty")
+	//ErrInvalidTTL is returned when SetWithTTL is given a TTL that is not positive.
+	ErrInvalidTTL = errors.New("ttl must be positive")
+	//ErrOverflow is returned when an increment would overflow the integer.
+	ErrOverflow = errors.New("increment or decrement would overflow")
+	//ErrNotFinite is returned when a floating point increment would produce NaN or Infinity.
+	ErrNotFinite = errors.New("increment would produce NaN or Infinity")
+	//ErrNegativeTimeout is returned when a blocking command is given a negative timeout.
+	ErrNegativeTimeout = errors.New("timeout must not be negative")
+	//ErrNoKeys is returned when a command that takes several keys is given none.
+	ErrNoKeys = errors.New("at least one key is required")
+	//ErrNegativeCount is returned when SetPop is given a negative count.
+	ErrNegativeCount = errors.New("count must not be negative")
+	//ErrZAddOptions is returned when ZAdd is given options that can't be combined.
+	ErrZAddOptions = errors.New("GT, LT, NX and XX options at the same time are not compatible")
+	//ErrScoreRange is returned when the bounds of a score range can't be parsed.
+	ErrScoreRange = errors.New("min or max is not a float")
+	//ErrLexRange is returned when the bounds of a lex range can't be parsed.
+	ErrLexRange = errors.New("min or max not valid string range item")
+	//ErrScoreNaN is returned when an increment would make a score NaN.
+	ErrScoreNaN = errors.New("resulting score is not a number (NaN)")
+	//ErrSameKey is returned when copying a key onto itself.
+	ErrSameKey = errors.New("source and destination objects are the same")
+)
+
+//OpError is the error returned by stores. It records the operation and the key it failed on. Use errors.Is to compare
+//the wrapped error against ErrNotFound and the other sentinel errors.
+type OpError struct {
+	Op  string
+	Key string
+	Err error
+}
+
+//Error returns the error message including the operation and the key.
+func (e *OpError) Error() string {
+	if e.Key == "" {
+		return "store: " + e.Op + ": " + e.Err.Error()
+	}
+	return "store: " + e.Op + " " + e.Key + ": " + e.Err.Error()
+}
+
+//Unwrap returns the underlying error.
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+//opError wraps err in an OpError, returning nil when err is nil.
+func opError(op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &OpError{Op: op, Key: key, Err: err}
+}
+
+//redisError wraps err in an OpError, mapping the redigo errors onto the store errors.
+func redisError(op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == redis.ErrNil {
+		err = ErrNotFound
+	} else if re, ok := err.(redis.Error); ok {
+		switch msg := string(re); {
+		case strings.HasPrefix(msg, "WRONGTYPE"):
+			err = ErrWrongType
+		case strings.HasPrefix(msg, "ERR value is not an integer"), strings.HasPrefix(msg, "ERR hash value is not an integer"):
+			err = ErrNotInteger
+		case strings.HasPrefix(msg, "ERR value is not a valid float"), strings.HasPrefix(msg, "ERR hash value is not a float"):
+			err = ErrNotFloat
+		case strings.HasPrefix(msg, "ERR no such key"):
+			err = ErrNotFound
+		case strings.HasPrefix(msg, "ERR index out of range"):
+			err = ErrIndexOutOfRange
+		case strings.HasPrefix(msg, "ERR increment or decrement would overflow"), strings.HasPrefix(msg, "ERR decrement would overflow"):
+			err = ErrOverflow
+		case strings.HasPrefix(msg, "ERR increment would produce NaN or Infinity"):
+			err = ErrNotFinite
+		case strings.HasPrefix(msg, "ERR min or max is not a float"):
+			err = ErrScoreRange
+		case strings.HasPrefix(msg, "ERR min or max not valid string range item"):
+			err = ErrLexRange
+		case strings.HasPrefix(msg, "ERR resulting score is not a number"):
+			err = ErrScoreNaN
+		case strings.HasPrefix(msg, "ERR source and destination objects are the same"):
+			err = ErrSameKey
+		}
+	}
+	return opError(op, key, err)
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//NoExpiry is the TTL returned for keys that exist without an expiry.
+const NoExpiry time.Duration = -1
+
+//milliseconds returns the duration in milliseconds, rounding positive durations below a millisecond up so they don't
+//expire the key straight away.
+func milliseconds(d time.Duration) int64 {
+	ms := int64(d / time.Millisecond)
+	if ms == 0 && d > 0 {
+		return 1
+	}
+	return ms
+}
+
+//TTL returns the time left before the key expires, or NoExpiry if the key has no expiry. ErrNotFound is returned for
+//missing keys.
+func (r *Redis) TTL(key string) (time.Duration, error) {
+	return r.TTLContext(context.Background(), key)
+}
+
+//TTLContext returns the time left before the key expires, or NoExpiry if the key has no expiry.
+func (r *Redis) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	ms, err := redis.Int64(r.do(ctx, "PTTL", key))
+	if err != nil {
+		return 0, redisError("TTL", key, err)
+	}
+	switch ms {
+	case -2:
+		return 0, opError("TTL", key, ErrNotFound)
+	case -1:
+		return NoExpiry, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+//Expire sets the expiry of the key with millisecond precision. A non positive TTL deletes the key.
+func (r *Redis) Expire(key string, ttl time.Duration) error {
+	return r.ExpireContext(context.Background(), key, ttl)
+}
+
+//ExpireContext sets the expiry of the key with millisecond precision.
+func (r *Redis) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := r.do(ctx, "PEXPIRE", key, milliseconds(ttl))
+	return redisError("Expire", key, err)
+}
+
+//ExpireAt sets the key to expire at the time. A time in the past deletes the key.
+func (r *Redis) ExpireAt(key string, t time.Time) error {
+	return r.ExpireAtContext(context.Background(), key, t)
+}
+
+//ExpireAtContext sets the key to expire at the time.
+func (r *Redis) ExpireAtContext(ctx context.Context, key string, t time.Time) error {
+	_, err := r.do(ctx, "PEXPIREAT", key, t.UnixNano()/int64(time.Millisecond))
+	return redisError("ExpireAt", key, err)
+}
+
+//Persist removes the expiry of the key.
+func (r *Redis) Persist(key string) error {
+	return r.PersistContext(context.Background(), key)
+}
+
+//PersistContext removes the expiry of the key.
+func (r *Redis) PersistContext(ctx context.Context, key string) error {
+	_, err := r.do(ctx, "PERSIST", key)
+	return redisError("Persist", key, err)
+}
+
+//SetWithTTL sets the value for the key together with its expiry in a single atomic command. The TTL must be positive.
+func (r *Redis) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	return r.SetWithTTLContext(context.Background(), key, value, ttl)
+}
+
+//SetWithTTLContext sets the value for the key together with its expiry in a single atomic command.
+func (r *Redis) SetWithTTLContext(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		return opError("SetWithTTL", key, ErrInvalidTTL)
+	}
+	_, err := r.do(ctx, "SET", key, value, "PX", milliseconds(ttl))
+	return redisError("SetWithTTL", key, err)
+}
+
+//expireAt sets the expiry of the entry stored at key, deleting it when the time has passed. The caller must hold the
+//lock.
+func (m *MemoryStore) expireAt(key string, t time.Time) {
+	e := m.lookup(key)
+	if e == nil {
+		return
+	}
+	if !t.After(time.Now()) {
+		m.deleteEntry(key)
+		return
+	}
+	e.expiresAt = t
+	m.modified(key)
+}
+
+//TTL returns the time left before the key expires, or NoExpiry if the key has no expiry. ErrNotFound is returned for
+//missing keys.
+func (m *MemoryStore) TTL(key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.lookup(key)
+	if e == nil {
+		return 0, opError("TTL", key, ErrNotFound)
+	}
+	if e.expiresAt.IsZero() {
+		return NoExpiry, nil
+	}
+	return time.Until(e.expiresAt).Truncate(time.Millisecond), nil
+}
+
+//Expire sets the expiry of the key with millisecond precision. A non positive TTL deletes the key.
+func (m *MemoryStore) Expire(key string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.expireLocked(key, ttl)
+}
+
+//expireLocked is Expire for callers holding the lock.
+func (m *MemoryStore) expireLocked(key string, ttl time.Duration) error {
+	m.expireAt(key, time.Now().Add(time.Duration(milliseconds(ttl))*time.Millisecond))
+	return nil
+}
+
+//ExpireAt sets the key to expire at the time. A time in the past deletes the key.
+func (m *MemoryStore) ExpireAt(key string, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.expireAtLocked(key, t)
+}
+
+//expireAtLocked is ExpireAt for callers holding the lock.
+func (m *MemoryStore) expireAtLocked(key string, t time.Time) error {
+	m.expireAt(key, t)
+	return nil
+}
+
+//Persist removes the expiry of the key.
+func (m *MemoryStore) Persist(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.persistLocked(key)
+}
+
+//persistLocked is Persist for callers holding the lock.
+func (m *MemoryStore) persistLocked(key string) error {
+	if e := m.lookup(key); e != nil && !e.expiresAt.IsZero() {
+		e.expiresAt = time.Time{}
+		m.modified(key)
+	}
+	return nil
+}
+
+//SetWithTTL sets the value for the key together with its expiry. The TTL must be positive.
+func (m *MemoryStore) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setWithTTLLocked(key, value, ttl)
+}
+
+//setWithTTLLocked is SetWithTTL for callers holding the lock.
+func (m *MemoryStore) setWithTTLLocked(key string, value interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		return opError("SetWithTTL", key, ErrInvalidTTL)
+	}
+	m.storeEntry(key, &memoryEntry{
+		value:     formatValue(value),
+		expiresAt: time.Now().Add(time.Duration(milliseconds(ttl)) * time.Millisecond),
+	})
+	return nil
+}
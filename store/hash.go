@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//GetHashInt64 returns the value of the hash key as an int64.
+func (r *Redis) GetHashInt64(key string, hash string) (int64, error) {
+	return r.GetHashInt64Context(context.Background(), key, hash)
+}
+
+//GetHashInt64Context returns the value of the hash key as an int64.
+func (r *Redis) GetHashInt64Context(ctx context.Context, key string, hash string) (int64, error) {
+	v, err := redis.Int64(r.do(ctx, "HGET", key, hash))
+	return v, redisError("GetHashInt64", key, err)
+}
+
+//GetHashFloat64 returns the value of the hash key as a float64.
+func (r *Redis) GetHashFloat64(key string, hash string) (float64, error) {
+	return r.GetHashFloat64Context(context.Background(), key, hash)
+}
+
+//GetHashFloat64Context returns the value of the hash key as a float64.
+func (r *Redis) GetHashFloat64Context(ctx context.Context, key string, hash string) (float64, error) {
+	v, err := redis.Float64(r.do(ctx, "HGET", key, hash))
+	return v, redisError("GetHashFloat64", key, err)
+}
+
+//GetHashBool returns the value of the hash key as a bool.
+func (r *Redis) GetHashBool(key string, hash string) (bool, error) {
+	return r.GetHashBoolContext(context.Background(), key, hash)
+}
+
+//GetHashBoolContext returns the value of the hash key as a bool.
+func (r *Redis) GetHashBoolContext(ctx context.Context, key string, hash string) (bool, error) {
+	v, err := redis.Bool(r.do(ctx, "HGET", key, hash))
+	return v, redisError("GetHashBool", key, err)
+}
+
+//HashExists returns true if the hash key exists.
+func (r *Redis) HashExists(key string, hash string) (bool, error) {
+	return r.HashExistsContext(context.Background(), key, hash)
+}
+
+//HashExistsContext returns true if the hash key exists.
+func (r *Redis) HashExistsContext(ctx context.Context, key string, hash string) (bool, error) {
+	v, err := redis.Bool(r.do(ctx, "HEXISTS", key, hash))
+	return v, redisError("HashExists", key, err)
+}
+
+//HashLength returns the number of keys of the hash. Missing keys have no hash keys.
+func (r *Redis) HashLength(key string) (int, error) {
+	return r.HashLengthContext(context.Background(), key)
+}
+
+//HashLengthContext returns the number of keys of the hash.
+func (r *Redis) HashLengthContext(ctx context.Context, key string) (int, error) {
+	v, err := redis.Int(r.do(ctx, "HLEN", key))
+	return v, redisError("HashLength", key, err)
+}
+
+//HashSetIfNotExists sets the value of the hash key only if it doesn't exist. It returns true if the value was set.
+func (r *Redis) HashSetIfNotExists(key string, hash string, value interface{}) (bool, error) {
+	return r.HashSetIfNotExistsContext(context.Background(), key, hash, value)
+}
+
+//HashSetIfNotExistsContext sets the value of the hash key only if it doesn't exist.
+func (r *Redis) HashSetIfNotExistsContext(ctx context.Context, key string, hash string, value interface{}) (bool, error) {
+	v, err := redis.Bool(r.do(ctx, "HSETNX", key, hash, value))
+	return v, redisError("HashSetIfNotExists", key, err)
+}
+
+//HashDelete deletes the hash keys in a single command and returns the number of keys deleted.
+func (r *Redis) HashDelete(key string, hashes ...string) (int, error) {
+	return r.HashDeleteContext(context.Background(), key, hashes...)
+}
+
+//HashDeleteContext deletes the hash keys and returns the number of keys deleted.
+func (r *Redis) HashDeleteContext(ctx context.Context, key string, hashes ...string) (int, error) {
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+	v, err := redis.Int(r.do(ctx, "HDEL", redis.Args{key}.AddFlat(hashes)...))
+	return v, redisError("HashDelete", key, err)
+}
+
+//hashField returns the value of the hash key, ErrNotFound if it doesn't exist. The caller must hold the lock.
+func (m *MemoryStore) hashField(key string, hash string) (string, error) {
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return "", err
+	}
+	v, ok := h[hash]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+//GetHashInt64 returns the value of the hash key as an int64.
+func (m *MemoryStore) GetHashInt64(key string, hash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.hashField(key, hash)
+	if err != nil {
+		return 0, opError("GetHashInt64", key, err)
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	return v, opError("GetHashInt64", key, err)
+}
+
+//GetHashFloat64 returns the value of the hash key as a float64.
+func (m *MemoryStore) GetHashFloat64(key string, hash string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.hashField(key, hash)
+	if err != nil {
+		return 0, opError("GetHashFloat64", key, err)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, opError("GetHashFloat64", key, err)
+}
+
+//GetHashBool returns the value of the hash key as a bool.
+func (m *MemoryStore) GetHashBool(key string, hash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.hashField(key, hash)
+	if err != nil {
+		return false, opError("GetHashBool", key, err)
+	}
+	v, err := strconv.ParseBool(s)
+	return v, opError("GetHashBool", key, err)
+}
+
+//HashExists returns true if the hash key exists.
+func (m *MemoryStore) HashExists(key string, hash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hashExistsLocked(key, hash)
+}
+
+//hashExistsLocked is HashExists for callers holding the lock.
+func (m *MemoryStore) hashExistsLocked(key string, hash string) (bool, error) {
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return false, opError("HashExists", key, err)
+	}
+	_, ok := h[hash]
+	return ok, nil
+}
+
+//HashLength returns the number of keys of the hash. Missing keys have no hash keys.
+func (m *MemoryStore) HashLength(key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.hashValue(key, false)
+	return len(h), opError("HashLength", key, err)
+}
+
+//HashSetIfNotExists sets the value of the hash key only if it doesn't exist. It returns true if the value was set.
+func (m *MemoryStore) HashSetIfNotExists(key string, hash string, value interface{}) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hashSetIfNotExistsLocked(key, hash, value)
+}
+
+//hashSetIfNotExistsLocked is HashSetIfNotExists for callers holding the lock.
+func (m *MemoryStore) hashSetIfNotExistsLocked(key string, hash string, value interface{}) (bool, error) {
+	h, err := m.hashValue(key, true)
+	if err != nil {
+		return false, opError("HashSetIfNotExists", key, err)
+	}
+	if _, ok := h[hash]; ok {
+		return false, nil
+	}
+	h[hash] = formatValue(value)
+	m.modified(key)
+	return true, nil
+}
+
+//HashDelete deletes the hash keys and returns the number of keys deleted.
+func (m *MemoryStore) HashDelete(key string, hashes ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hashDeleteLocked(key, hashes)
+}
+
+//hashDeleteLocked is HashDelete for callers holding the lock.
+func (m *MemoryStore) hashDeleteLocked(key string, hashes []string) (int, error) {
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return 0, opError("HashDelete", key, err)
+	}
+	n := 0
+	for _, hash := range hashes {
+		if _, ok := h[hash]; ok {
+			delete(h, hash)
+			n++
+		}
+	}
+	if n > 0 {
+		m.modified(key)
+	}
+	if h != nil && len(h) == 0 {
+		m.deleteEntry(key)
+	}
+	return n, nil
+}
@@ -0,0 +1,365 @@
+package store
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+//hashField is an exported struct field mapped onto a hash field.
+type hashField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+}
+
+var (
+	hashFieldsMu    sync.RWMutex
+	hashFieldsCache = make(map[reflect.Type][]hashField)
+)
+
+//hashFieldsForType returns the hash fields of the struct type, caching the result.
+func hashFieldsForType(t reflect.Type) []hashField {
+	hashFieldsMu.RLock()
+	fields, ok := hashFieldsCache[t]
+	hashFieldsMu.RUnlock()
+	if ok {
+		return fields
+	}
+
+	fields = compileHashFields(t)
+
+	hashFieldsMu.Lock()
+	hashFieldsCache[t] = fields
+	hashFieldsMu.Unlock()
+	return fields
+}
+
+//compileHashFields returns the hash fields of the struct type. Untagged embedded structs and exported pointers to
+//structs are flattened into the parent, and fields sharing a name are resolved like encoding/json does: the shallowest
+//field wins, a tagged field wins over untagged ones at the same depth, and fields that still conflict are dropped.
+func compileHashFields(t reflect.Type) []hashField {
+	all := collectHashFields(t, nil, map[reflect.Type]bool{t: true})
+
+	byName := make(map[string][]hashField, len(all))
+	for _, f := range all {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	fields := make([]hashField, 0, len(all))
+	for _, f := range all {
+		if dominant, ok := dominantHashField(byName[f.name]); ok && sameIndex(dominant.index, f.index) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+//collectHashFields walks the fields of the struct type and the structs embedded in it. visiting holds the struct types
+//on the current path, so a type embedding a pointer to itself isn't walked forever.
+func collectHashFields(t reflect.Type, index []int, visiting map[reflect.Type]bool) []hashField {
+	var fields []hashField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("store")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+		if embedded := embeddedStruct(f); embedded != nil && name == "" {
+			if !visiting[embedded] {
+				visiting[embedded] = true
+				fields = append(fields, collectHashFields(embedded, fieldIndex, visiting)...)
+				delete(visiting, embedded)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = f.Name
+		}
+		fields = append(fields, hashField{
+			name:      name,
+			index:     fieldIndex,
+			tagged:    tagged,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+	return fields
+}
+
+//embeddedStruct returns the struct type of an embedded field that is flattened into its parent, or nil. Embedded
+//structs are flattened unless they implement encoding.TextMarshaler, and embedded pointers to structs only when they
+//are exported, since GetHashStruct has to allocate them.
+func embeddedStruct(f reflect.StructField) reflect.Type {
+	if !f.Anonymous {
+		return nil
+	}
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		if f.PkgPath != "" {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || isTextMarshaler(t) {
+		return nil
+	}
+	return t
+}
+
+//dominantHashField returns the field that wins among fields sharing a name. It returns false when the fields
+//conflict.
+func dominantHashField(fields []hashField) (hashField, bool) {
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		if len(f.index) < depth {
+			depth = len(f.index)
+		}
+	}
+
+	var shallowest []hashField
+	tagged := -1
+	for _, f := range fields {
+		if len(f.index) != depth {
+			continue
+		}
+		if f.tagged {
+			if tagged >= 0 {
+				return hashField{}, false
+			}
+			tagged = len(shallowest)
+		}
+		shallowest = append(shallowest, f)
+	}
+	if tagged >= 0 {
+		return shallowest[tagged], true
+	}
+	if len(shallowest) > 1 {
+		return hashField{}, false
+	}
+	return shallowest[0], true
+}
+
+//sameIndex returns true if the field indexes are equal.
+func sameIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//isTextMarshaler returns true if the type or a pointer to it implements encoding.TextMarshaler.
+func isTextMarshaler(t reflect.Type) bool {
+	return t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+//fieldByIndex returns the field of the struct with the index. It returns false when an embedded pointer on the way is
+//nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+//allocFieldByIndex returns the field of the struct with the index, allocating the nil embedded pointers on the way.
+func allocFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+//isEmptyValue returns true if the value is the zero value of an omitempty field.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+	}
+	return false
+}
+
+//flattenHashStruct returns the alternating hash field names and formatted values of the struct. Nil pointer fields
+//are left out.
+func flattenHashStruct(v interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+
+	var args []interface{}
+	for _, f := range hashFieldsForType(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		s, err := formatHashField(fv)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", f.name, err)
+		}
+		args = append(args, f.name, s)
+	}
+	return args, nil
+}
+
+//formatHashField formats the field value, using MarshalText when the type implements encoding.TextMarshaler, with
+//a value or, for addressable values, a pointer receiver.
+func formatHashField(v reflect.Value) (string, error) {
+	if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return "", fmt.Errorf("unsupported type %s", v.Type())
+		}
+		return string(v.Bytes()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return formatValue(v.Interface()), nil
+}
+
+//structPointer returns the struct pointed to by dst.
+func structPointer(dst interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, ErrNotStructPointer
+	}
+	return rv.Elem(), nil
+}
+
+//scanHashStruct assigns the hash fields to the matching fields of the struct. Hash fields without a matching struct
+//field are ignored and struct fields missing from the hash are left unchanged.
+func scanHashStruct(h map[string]string, dst reflect.Value) error {
+	for _, f := range hashFieldsForType(dst.Type()) {
+		s, ok := h[f.name]
+		if !ok {
+			continue
+		}
+		if err := assignHashField(allocFieldByIndex(dst, f.index), s); err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+	}
+	return nil
+}
+
+//assignHashField parses the hash field value into the struct field.
+func assignHashField(v reflect.Value, s string) error {
+	if v.CanAddr() {
+		if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return tu.UnmarshalText([]byte(s))
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.SetBytes([]byte(s))
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assignHashField(v.Elem(), s)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
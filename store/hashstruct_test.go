@@ -0,0 +1,88 @@
+package store_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/awkhan/go-store/store"
+	"github.com/stretchr/testify/assert"
+)
+
+type HashAudit struct {
+	Version int
+	Shared  string
+}
+
+type hashOwner struct {
+	Name   string
+	Owner  string
+	Shared string
+}
+
+type hashLabel struct {
+	Label string `store:"Owner"`
+}
+
+//hashRecord embeds structs whose fields conflict with each other and with its own fields.
+type hashRecord struct {
+	*HashAudit
+	hashOwner
+	hashLabel
+	Name string
+}
+
+//upperText only implements encoding.TextMarshaler with a pointer receiver.
+type upperText string
+
+func (u *upperText) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(*u))), nil
+}
+
+func (u *upperText) UnmarshalText(b []byte) error {
+	*u = upperText(strings.ToLower(string(b)))
+	return nil
+}
+
+type hashText struct {
+	Text upperText
+}
+
+func TestHashStructShadowedFields(t *testing.T) {
+	ms := store.NewMemoryStore()
+
+	v := hashRecord{
+		HashAudit: &HashAudit{Version: 3, Shared: "audit"},
+		hashOwner: hashOwner{Name: "embedded", Owner: "owner", Shared: "owner"},
+		hashLabel: hashLabel{Label: "label"},
+		Name:      "outer",
+	}
+	assert.Nil(t, ms.SetHashStruct("key", &v), "Error setting hash struct")
+
+	all, _ := ms.HGetAll("key")
+	assert.Equal(t, map[string]string{"Name": "outer", "Version": "3", "Owner": "label"}, all,
+		"The shallowest field should win, a tagged field should win at the same depth and other conflicts should be dropped")
+
+	var r hashRecord
+	assert.Nil(t, ms.GetHashStruct("key", &r), "Error fetching hash struct")
+	assert.Equal(t, "outer", r.Name, "The outer field should be read")
+	assert.Equal(t, "", r.hashOwner.Name, "The shadowed field should not be read")
+	assert.Equal(t, "label", r.Label, "The tagged field should be read")
+	assert.Equal(t, &HashAudit{Version: 3}, r.HashAudit, "The embedded pointer should be allocated")
+
+	v.HashAudit = nil
+	assert.Nil(t, ms.SetHashStruct("other", v), "Error setting hash struct with a nil embedded pointer")
+	all, _ = ms.HGetAll("other")
+	assert.Equal(t, map[string]string{"Name": "outer", "Owner": "label"}, all, "Fields of a nil embedded pointer should be left out")
+}
+
+func TestHashStructPointerTextMarshaler(t *testing.T) {
+	ms := store.NewMemoryStore()
+
+	assert.Nil(t, ms.SetHashStruct("key", &hashText{Text: "abc"}), "Error setting hash struct")
+	v, _ := ms.GetHashString("key", "Text")
+	assert.Equal(t, "ABC", v, "MarshalText with a pointer receiver should be used")
+
+	var r hashText
+	assert.Nil(t, ms.GetHashStruct("key", &r), "Error fetching hash struct")
+	assert.Equal(t, upperText("abc"), r.Text, "Invalid fetched field")
+}
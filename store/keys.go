@@ -0,0 +1,297 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//KeyType is the kind of value stored at a key.
+type KeyType int
+
+const (
+	//KeyTypeNone is the type of missing keys.
+	KeyTypeNone KeyType = iota
+	//KeyTypeString is the type of string values, including numbers.
+	KeyTypeString
+	//KeyTypeHash is the type of hashes.
+	KeyTypeHash
+	//KeyTypeList is the type of lists.
+	KeyTypeList
+	//KeyTypeSet is the type of sets.
+	KeyTypeSet
+	//KeyTypeZSet is the type of sorted sets.
+	KeyTypeZSet
+)
+
+//keyTypeNames are the names redis uses for the key types.
+var keyTypeNames = map[KeyType]string{
+	KeyTypeNone:   "none",
+	KeyTypeString: "string",
+	KeyTypeHash:   "hash",
+	KeyTypeList:   "list",
+	KeyTypeSet:    "set",
+	KeyTypeZSet:   "zset",
+}
+
+//String returns the name redis uses for the type.
+func (t KeyType) String() string {
+	if name, ok := keyTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("KeyType(%d)", int(t))
+}
+
+//parseKeyType returns the type named by redis.
+func parseKeyType(name string) (KeyType, error) {
+	for t, n := range keyTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return KeyTypeNone, fmt.Errorf("unsupported key type %s", name)
+}
+
+//opKey returns the key reported by errors of operations on several keys, which is only set when there is one key.
+func opKey(keys []string) string {
+	if len(keys) == 1 {
+		return keys[0]
+	}
+	return ""
+}
+
+//countKeys runs a command taking several keys that replies with a count.
+func (r *Redis) countKeys(ctx context.Context, op, cmd string, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	n, err := redis.Int(r.do(ctx, cmd, redis.Args{}.AddFlat(keys)...))
+	return n, redisError(op, opKey(keys), err)
+}
+
+//Unlink deletes the keys and returns the number of keys deleted. Unlike DeleteKey the memory of large values is freed
+//in the background, so the server isn't blocked.
+func (r *Redis) Unlink(keys ...string) (int, error) {
+	return r.UnlinkContext(context.Background(), keys...)
+}
+
+//UnlinkContext deletes the keys and returns the number of keys deleted.
+func (r *Redis) UnlinkContext(ctx context.Context, keys ...string) (int, error) {
+	return r.countKeys(ctx, "Unlink", "UNLINK", keys)
+}
+
+//Exists returns the number of the keys that exist. A key given more than once is counted every time.
+func (r *Redis) Exists(keys ...string) (int, error) {
+	return r.ExistsContext(context.Background(), keys...)
+}
+
+//ExistsContext returns the number of the keys that exist.
+func (r *Redis) ExistsContext(ctx context.Context, keys ...string) (int, error) {
+	return r.countKeys(ctx, "Exists", "EXISTS", keys)
+}
+
+//Touch updates the last access time of the keys, which the server uses to pick keys to evict, and returns the number
+//of keys that exist.
+func (r *Redis) Touch(keys ...string) (int, error) {
+	return r.TouchContext(context.Background(), keys...)
+}
+
+//TouchContext updates the last access time of the keys and returns the number of keys that exist.
+func (r *Redis) TouchContext(ctx context.Context, keys ...string) (int, error) {
+	return r.countKeys(ctx, "Touch", "TOUCH", keys)
+}
+
+//Type returns the type of the value stored at key, KeyTypeNone if the key doesn't exist.
+func (r *Redis) Type(key string) (KeyType, error) {
+	return r.TypeContext(context.Background(), key)
+}
+
+//TypeContext returns the type of the value stored at key.
+func (r *Redis) TypeContext(ctx context.Context, key string) (KeyType, error) {
+	name, err := redis.String(r.do(ctx, "TYPE", key))
+	if err != nil {
+		return KeyTypeNone, redisError("Type", key, err)
+	}
+	t, err := parseKeyType(name)
+	return t, opError("Type", key, err)
+}
+
+//Rename renames the key to newKey, replacing any value stored at newKey. The expiry of the key is kept. ErrNotFound is
+//returned when the key doesn't exist.
+func (r *Redis) Rename(key, newKey string) error {
+	return r.RenameContext(context.Background(), key, newKey)
+}
+
+//RenameContext renames the key to newKey, replacing any value stored at newKey.
+func (r *Redis) RenameContext(ctx context.Context, key, newKey string) error {
+	_, err := r.do(ctx, "RENAME", key, newKey)
+	return redisError("Rename", key, err)
+}
+
+//RenameIfNotExists renames the key to newKey only if newKey doesn't exist. It returns true if the key was renamed.
+//ErrNotFound is returned when the key doesn't exist.
+func (r *Redis) RenameIfNotExists(key, newKey string) (bool, error) {
+	return r.RenameIfNotExistsContext(context.Background(), key, newKey)
+}
+
+//RenameIfNotExistsContext renames the key to newKey only if newKey doesn't exist.
+func (r *Redis) RenameIfNotExistsContext(ctx context.Context, key, newKey string) (bool, error) {
+	v, err := redis.Bool(r.do(ctx, "RENAMENX", key, newKey))
+	return v, redisError("RenameIfNotExists", key, err)
+}
+
+//Copy copies the value stored at src along with its expiry to dst. An existing dst is only replaced when replace is
+//set. It returns true if the value was copied and false if src doesn't exist or dst exists. Copy needs redis 6.2.
+func (r *Redis) Copy(src, dst string, replace bool) (bool, error) {
+	return r.CopyContext(context.Background(), src, dst, replace)
+}
+
+//CopyContext copies the value stored at src along with its expiry to dst.
+func (r *Redis) CopyContext(ctx context.Context, src, dst string, replace bool) (bool, error) {
+	args := redis.Args{src, dst}
+	if replace {
+		args = args.Add("REPLACE")
+	}
+	v, err := redis.Bool(r.do(ctx, "COPY", args...))
+	return v, redisError("Copy", src, err)
+}
+
+//Unlink deletes the keys and returns the number of keys deleted.
+func (m *MemoryStore) Unlink(keys ...string) (int, error) {
+	return m.DeleteKey(keys...)
+}
+
+//Exists returns the number of the keys that exist. A key given more than once is counted every time.
+func (m *MemoryStore) Exists(keys ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.existsLocked(keys)
+}
+
+//existsLocked is Exists for callers holding the lock.
+func (m *MemoryStore) existsLocked(keys []string) (int, error) {
+	n := 0
+	for _, k := range keys {
+		if m.lookup(k) != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+//Touch returns the number of the keys that exist. The memory store doesn't evict keys, so there is nothing to update.
+func (m *MemoryStore) Touch(keys ...string) (int, error) {
+	return m.Exists(keys...)
+}
+
+//Type returns the type of the value stored at key, KeyTypeNone if the key doesn't exist.
+func (m *MemoryStore) Type(key string) (KeyType, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.lookup(key)
+	if e == nil {
+		return KeyTypeNone, nil
+	}
+	switch e.value.(type) {
+	case string:
+		return KeyTypeString, nil
+	case map[string]string:
+		return KeyTypeHash, nil
+	case []string:
+		return KeyTypeList, nil
+	case map[string]struct{}:
+		return KeyTypeSet, nil
+	case *sortedSet:
+		return KeyTypeZSet, nil
+	}
+	return KeyTypeNone, opError("Type", key, fmt.Errorf("unsupported value %T", e.value))
+}
+
+//Rename renames the key to newKey, replacing any value stored at newKey. The expiry of the key is kept. ErrNotFound is
+//returned when the key doesn't exist.
+func (m *MemoryStore) Rename(key, newKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.renameLocked(key, newKey)
+}
+
+//renameLocked is Rename for callers holding the lock.
+func (m *MemoryStore) renameLocked(key, newKey string) error {
+	e := m.lookup(key)
+	if e == nil {
+		return opError("Rename", key, ErrNotFound)
+	}
+	m.deleteEntry(key)
+	m.storeEntry(newKey, e)
+	return nil
+}
+
+//RenameIfNotExists renames the key to newKey only if newKey doesn't exist. It returns true if the key was renamed.
+//ErrNotFound is returned when the key doesn't exist.
+func (m *MemoryStore) RenameIfNotExists(key, newKey string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.renameIfNotExistsLocked(key, newKey)
+}
+
+//renameIfNotExistsLocked is RenameIfNotExists for callers holding the lock.
+func (m *MemoryStore) renameIfNotExistsLocked(key, newKey string) (bool, error) {
+	e := m.lookup(key)
+	if e == nil {
+		return false, opError("RenameIfNotExists", key, ErrNotFound)
+	}
+	if m.lookup(newKey) != nil {
+		return false, nil
+	}
+	m.deleteEntry(key)
+	m.storeEntry(newKey, e)
+	return true, nil
+}
+
+//Copy copies the value stored at src along with its expiry to dst. An existing dst is only replaced when replace is
+//set. It returns true if the value was copied and false if src doesn't exist or dst exists.
+func (m *MemoryStore) Copy(src, dst string, replace bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.copyLocked(src, dst, replace)
+}
+
+//copyLocked is Copy for callers holding the lock.
+func (m *MemoryStore) copyLocked(src, dst string, replace bool) (bool, error) {
+	if src == dst {
+		return false, opError("Copy", src, ErrSameKey)
+	}
+	e := m.lookup(src)
+	if e == nil {
+		return false, nil
+	}
+	if !replace && m.lookup(dst) != nil {
+		return false, nil
+	}
+	m.storeEntry(dst, &memoryEntry{value: copyValue(e.value), expiresAt: e.expiresAt})
+	return true, nil
+}
+
+//copyValue returns a deep copy of a value held by the memory store.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]string:
+		c := make(map[string]string, len(v))
+		for k, s := range v {
+			c[k] = s
+		}
+		return c
+	case map[string]struct{}:
+		c := make(map[string]struct{}, len(v))
+		for k := range v {
+			c[k] = struct{}{}
+		}
+		return c
+	case []string:
+		return append([]string{}, v...)
+	case *sortedSet:
+		return v.clone()
+	}
+	return value
+}
@@ -0,0 +1,258 @@
+package store
+
+import (
+	"context"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//ItemFromList returns the item at the index of the list converted to the data type. Negative indexes count from the
+//end of the list. ErrNotFound is returned when the index is out of range.
+func (r *Redis) ItemFromList(key string, dataType int, index int) (interface{}, error) {
+	return r.ItemFromListContext(context.Background(), key, dataType, index)
+}
+
+//ItemFromListContext returns the item at the index of the list converted to the data type.
+func (r *Redis) ItemFromListContext(ctx context.Context, key string, dataType int, index int) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("ItemFromList", key, ErrInvalidDataType)
+	}
+
+	val, err := redis.String(r.do(ctx, "LINDEX", key, index))
+	if err != nil {
+		return nil, redisError("ItemFromList", key, err)
+	}
+
+	item, err := convertItem(val, dataType)
+	return item, opError("ItemFromList", key, err)
+}
+
+//SetItemInList replaces the item at the index of the list. Negative indexes count from the end of the list.
+//ErrNotFound is returned when the list doesn't exist and ErrIndexOutOfRange when the index is out of range.
+func (r *Redis) SetItemInList(key string, index int, value interface{}) error {
+	return r.SetItemInListContext(context.Background(), key, index, value)
+}
+
+//SetItemInListContext replaces the item at the index of the list.
+func (r *Redis) SetItemInListContext(ctx context.Context, key string, index int, value interface{}) error {
+	_, err := r.do(ctx, "LSET", key, index, value)
+	return redisError("SetItemInList", key, err)
+}
+
+//TrimList trims the list so it only holds the items from start to end, which keeps capped lists such as the last N
+//events when called after every push. The list is deleted when the range is empty.
+func (r *Redis) TrimList(key string, start, end int) error {
+	return r.TrimListContext(context.Background(), key, start, end)
+}
+
+//TrimListContext trims the list so it only holds the items from start to end.
+func (r *Redis) TrimListContext(ctx context.Context, key string, start, end int) error {
+	_, err := r.do(ctx, "LTRIM", key, start, end)
+	return redisError("TrimList", key, err)
+}
+
+//InsertItemInList inserts the value before or after the first item of the list equal to pivot and returns the new
+//length of the list. ErrNotFound is returned when the list or the pivot doesn't exist.
+func (r *Redis) InsertItemInList(key string, pivot, value interface{}, before bool) (int, error) {
+	return r.InsertItemInListContext(context.Background(), key, pivot, value, before)
+}
+
+//InsertItemInListContext inserts the value before or after the first item of the list equal to pivot.
+func (r *Redis) InsertItemInListContext(ctx context.Context, key string, pivot, value interface{}, before bool) (int, error) {
+	where := "AFTER"
+	if before {
+		where = "BEFORE"
+	}
+
+	n, err := redis.Int(r.do(ctx, "LINSERT", key, where, pivot, value))
+	if err != nil {
+		return 0, redisError("InsertItemInList", key, err)
+	}
+	if n <= 0 {
+		return 0, opError("InsertItemInList", key, ErrNotFound)
+	}
+	return n, nil
+}
+
+//MoveItemToList pops an item from the end of the src list, pushes it to the front of the dst list and returns it
+//converted to the data type. The move is atomic, so an item being processed is never lost. ErrNotFound is returned
+//when src is empty.
+func (r *Redis) MoveItemToList(src, dst string, dataType int) (interface{}, error) {
+	return r.MoveItemToListContext(context.Background(), src, dst, dataType)
+}
+
+//MoveItemToListContext moves an item from the end of the src list to the front of the dst list.
+func (r *Redis) MoveItemToListContext(ctx context.Context, src, dst string, dataType int) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("MoveItemToList", src, ErrInvalidDataType)
+	}
+
+	val, err := redis.String(r.do(ctx, "RPOPLPUSH", src, dst))
+	if err != nil {
+		return nil, redisError("MoveItemToList", src, err)
+	}
+
+	item, err := convertItem(val, dataType)
+	return item, opError("MoveItemToList", src, err)
+}
+
+//listIndex converts a possibly negative index of a list of length n to a positive one. It returns false when the index
+//is out of range.
+func listIndex(index, n int) (int, bool) {
+	if index < 0 {
+		index += n
+	}
+	return index, index >= 0 && index < n
+}
+
+//moveItem moves an item from the end of the src list to the front of the dst list. It returns false when src is empty.
+//The caller must hold the lock.
+func (m *MemoryStore) moveItem(src, dst string) (string, bool, error) {
+	l, ok, err := m.listValue(src)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	d, _, err := m.listValue(dst)
+	if err != nil {
+		return "", false, err
+	}
+
+	item := l[len(l)-1]
+	l = l[:len(l)-1]
+	m.storeList(src, l)
+	if src == dst {
+		d = l
+	}
+	m.storeList(dst, append([]string{item}, d...))
+	return item, true, nil
+}
+
+//ItemFromList returns the item at the index of the list converted to the data type. Negative indexes count from the
+//end of the list. ErrNotFound is returned when the index is out of range.
+func (m *MemoryStore) ItemFromList(key string, dataType int, index int) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.itemFromListLocked(key, dataType, index)
+}
+
+//itemFromListLocked is ItemFromList for callers holding the lock.
+func (m *MemoryStore) itemFromListLocked(key string, dataType int, index int) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("ItemFromList", key, ErrInvalidDataType)
+	}
+
+	l, _, err := m.listValue(key)
+	if err != nil {
+		return nil, opError("ItemFromList", key, err)
+	}
+	i, ok := listIndex(index, len(l))
+	if !ok {
+		return nil, opError("ItemFromList", key, ErrNotFound)
+	}
+
+	item, err := convertItem(l[i], dataType)
+	return item, opError("ItemFromList", key, err)
+}
+
+//SetItemInList replaces the item at the index of the list. Negative indexes count from the end of the list.
+//ErrNotFound is returned when the list doesn't exist and ErrIndexOutOfRange when the index is out of range.
+func (m *MemoryStore) SetItemInList(key string, index int, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setItemInListLocked(key, index, value)
+}
+
+//setItemInListLocked is SetItemInList for callers holding the lock.
+func (m *MemoryStore) setItemInListLocked(key string, index int, value interface{}) error {
+	l, ok, err := m.listValue(key)
+	if err != nil {
+		return opError("SetItemInList", key, err)
+	}
+	if !ok {
+		return opError("SetItemInList", key, ErrNotFound)
+	}
+	i, ok := listIndex(index, len(l))
+	if !ok {
+		return opError("SetItemInList", key, ErrIndexOutOfRange)
+	}
+	l[i] = formatValue(value)
+	m.modified(key)
+	return nil
+}
+
+//TrimList trims the list so it only holds the items from start to end. The list is deleted when the range is empty.
+func (m *MemoryStore) TrimList(key string, start, end int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.trimListLocked(key, start, end)
+}
+
+//trimListLocked is TrimList for callers holding the lock.
+func (m *MemoryStore) trimListLocked(key string, start, end int) error {
+	l, ok, err := m.listValue(key)
+	if err != nil {
+		return opError("TrimList", key, err)
+	}
+	if !ok {
+		return nil
+	}
+	m.storeList(key, append([]string{}, listRange(l, start, end)...))
+	return nil
+}
+
+//InsertItemInList inserts the value before or after the first item of the list equal to pivot and returns the new
+//length of the list. ErrNotFound is returned when the list or the pivot doesn't exist.
+func (m *MemoryStore) InsertItemInList(key string, pivot, value interface{}, before bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.insertItemInListLocked(key, pivot, value, before)
+}
+
+//insertItemInListLocked is InsertItemInList for callers holding the lock.
+func (m *MemoryStore) insertItemInListLocked(key string, pivot, value interface{}, before bool) (int, error) {
+	l, _, err := m.listValue(key)
+	if err != nil {
+		return 0, opError("InsertItemInList", key, err)
+	}
+
+	p := formatValue(pivot)
+	for i, item := range l {
+		if item != p {
+			continue
+		}
+		if !before {
+			i++
+		}
+		inserted := make([]string, 0, len(l)+1)
+		inserted = append(append(append(inserted, l[:i]...), formatValue(value)), l[i:]...)
+		m.storeList(key, inserted)
+		return len(inserted), nil
+	}
+	return 0, opError("InsertItemInList", key, ErrNotFound)
+}
+
+//MoveItemToList pops an item from the end of the src list, pushes it to the front of the dst list and returns it
+//converted to the data type. ErrNotFound is returned when src is empty.
+func (m *MemoryStore) MoveItemToList(src, dst string, dataType int) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.moveItemToListLocked(src, dst, dataType)
+}
+
+//moveItemToListLocked is MoveItemToList for callers holding the lock.
+func (m *MemoryStore) moveItemToListLocked(src, dst string, dataType int) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("MoveItemToList", src, ErrInvalidDataType)
+	}
+
+	v, ok, err := m.moveItem(src, dst)
+	if err != nil {
+		return nil, opError("MoveItemToList", src, err)
+	}
+	if !ok {
+		return nil, opError("MoveItemToList", src, ErrNotFound)
+	}
+
+	item, err := convertItem(v, dataType)
+	return item, opError("MoveItemToList", src, err)
+}
@@ -0,0 +1,793 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//memoryEntry is a single value held by the memory store along with its expiry.
+type memoryEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+//expired returns true if the entry has an expiry that has passed.
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+//MemoryStore provides an in-memory implementation of the store that mirrors redis semantics.
+type MemoryStore struct {
+	//TxRetries is the number of times a transaction is retried after a conflict. DefaultTxRetries is used when it is
+	//not positive.
+	TxRetries int
+
+	mu     sync.Mutex
+	data   map[string]*memoryEntry
+	pushed *listSignal
+	//watched tracks the writes to the keys watched by running transactions.
+	watched map[string]*watchedKey
+}
+
+//NewMemoryStore creates a new empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data:   make(map[string]*memoryEntry),
+		pushed: newListSignal(),
+	}
+}
+
+//lookup returns the live entry for the key, evicting it if it has expired. The caller must hold the lock.
+func (m *MemoryStore) lookup(key string) *memoryEntry {
+	e, ok := m.data[key]
+	if !ok {
+		return nil
+	}
+	if e.expired(time.Now()) {
+		m.deleteEntry(key)
+		return nil
+	}
+	return e
+}
+
+//modified records a write to the value stored at key, so transactions watching the key retry. Every write must call
+//it, which storeEntry and deleteEntry do. The caller must hold the lock.
+func (m *MemoryStore) modified(key string) {
+	if w, ok := m.watched[key]; ok {
+		w.version++
+	}
+}
+
+//storeEntry stores the entry at key, waking the callers blocked on empty lists when it holds a list. The caller must
+//hold the lock.
+func (m *MemoryStore) storeEntry(key string, e *memoryEntry) {
+	m.data[key] = e
+	m.modified(key)
+	if _, ok := e.value.([]string); ok {
+		m.pushed.broadcast()
+	}
+}
+
+//deleteEntry deletes the entry stored at key. The caller must hold the lock.
+func (m *MemoryStore) deleteEntry(key string) {
+	delete(m.data, key)
+	m.modified(key)
+}
+
+//stringValue returns the string stored at key. The caller must hold the lock.
+func (m *MemoryStore) stringValue(key string) (string, bool, error) {
+	e := m.lookup(key)
+	if e == nil {
+		return "", false, nil
+	}
+	s, ok := e.value.(string)
+	if !ok {
+		return "", false, ErrWrongType
+	}
+	return s, true, nil
+}
+
+//hashValue returns the hash stored at key, creating it when create is set. The caller must hold the lock.
+func (m *MemoryStore) hashValue(key string, create bool) (map[string]string, error) {
+	e := m.lookup(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		h := make(map[string]string)
+		m.storeEntry(key, &memoryEntry{value: h})
+		return h, nil
+	}
+	h, ok := e.value.(map[string]string)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	return h, nil
+}
+
+//setValue returns the set stored at key, creating it when create is set. The caller must hold the lock.
+func (m *MemoryStore) setValue(key string, create bool) (map[string]struct{}, error) {
+	e := m.lookup(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		s := make(map[string]struct{})
+		m.storeEntry(key, &memoryEntry{value: s})
+		return s, nil
+	}
+	s, ok := e.value.(map[string]struct{})
+	if !ok {
+		return nil, ErrWrongType
+	}
+	return s, nil
+}
+
+//listValue returns the list stored at key. The caller must hold the lock.
+func (m *MemoryStore) listValue(key string) ([]string, bool, error) {
+	e := m.lookup(key)
+	if e == nil {
+		return nil, false, nil
+	}
+	l, ok := e.value.([]string)
+	if !ok {
+		return nil, false, ErrWrongType
+	}
+	return l, true, nil
+}
+
+//storeList stores the list at key, removing the key when the list is empty to match redis. Callers blocked on empty
+//lists are woken up when the list is not empty. The caller must hold the lock.
+func (m *MemoryStore) storeList(key string, l []string) {
+	if len(l) == 0 {
+		m.deleteEntry(key)
+		return
+	}
+	m.pushed.broadcast()
+	if e := m.lookup(key); e != nil {
+		e.value = l
+		m.modified(key)
+		return
+	}
+	m.storeEntry(key, &memoryEntry{value: l})
+}
+
+//DeleteKey deletes the keys from the memory store and returns the number of keys deleted.
+func (m *MemoryStore) DeleteKey(keys ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteKeyLocked(keys...)
+}
+
+//deleteKeyLocked is DeleteKey for callers holding the lock.
+func (m *MemoryStore) deleteKeyLocked(keys ...string) (int, error) {
+	n := 0
+	for _, k := range keys {
+		if m.lookup(k) != nil {
+			m.deleteEntry(k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+//GetString retrieves the string data stored in the memory store.
+func (m *MemoryStore) GetString(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.getStringLocked(key)
+}
+
+//getStringLocked is GetString for callers holding the lock.
+func (m *MemoryStore) getStringLocked(key string) (string, error) {
+	s, err := m.storedString(key)
+	return s, opError("GetString", key, err)
+}
+
+//GetInt64 retrieves the int64 data stored in the memory store.
+func (m *MemoryStore) GetInt64(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.getInt64Locked(key)
+}
+
+//getInt64Locked is GetInt64 for callers holding the lock.
+func (m *MemoryStore) getInt64Locked(key string) (int64, error) {
+	s, err := m.storedString(key)
+	if err != nil {
+		return 0, opError("GetInt64", key, err)
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, opError("GetInt64", key, err)
+}
+
+//MGetStrings retrieves the string data stored at the keys. Missing keys and keys holding other types are left out of
+//the map.
+func (m *MemoryStore) MGetStrings(keys ...string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vals := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if s, ok, err := m.stringValue(k); ok && err == nil {
+			vals[k] = s
+		}
+	}
+	return vals, nil
+}
+
+//Set sets the value for the specified key.
+func (m *MemoryStore) Set(key string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setLocked(key, value)
+}
+
+//setLocked is Set for callers holding the lock.
+func (m *MemoryStore) setLocked(key string, value interface{}) error {
+	m.storeEntry(key, &memoryEntry{value: formatValue(value)})
+	return nil
+}
+
+//MSet sets the values for the keys.
+func (m *MemoryStore) MSet(values map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, v := range values {
+		m.storeEntry(k, &memoryEntry{value: formatValue(v)})
+	}
+	return nil
+}
+
+//SetHash sets the value for the specific hash key.
+func (m *MemoryStore) SetHash(key string, hash string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setHashLocked(key, hash, value)
+}
+
+//setHashLocked is SetHash for callers holding the lock.
+func (m *MemoryStore) setHashLocked(key string, hash string, value interface{}) error {
+	h, err := m.hashValue(key, true)
+	if err != nil {
+		return opError("SetHash", key, err)
+	}
+	h[hash] = formatValue(value)
+	m.modified(key)
+	return nil
+}
+
+//DeleteHash deletes the hash value for the specific key.
+func (m *MemoryStore) DeleteHash(key string, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteHashLocked(key, hash)
+}
+
+//deleteHashLocked is DeleteHash for callers holding the lock.
+func (m *MemoryStore) deleteHashLocked(key string, hash string) error {
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return opError("DeleteHash", key, err)
+	}
+	if _, ok := h[hash]; !ok {
+		return nil
+	}
+	delete(h, hash)
+	m.modified(key)
+	if len(h) == 0 {
+		m.deleteEntry(key)
+	}
+	return nil
+}
+
+//GetHashString returns the string value of the hash.
+func (m *MemoryStore) GetHashString(key string, hash string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.getHashStringLocked(key, hash)
+}
+
+//getHashStringLocked is GetHashString for callers holding the lock.
+func (m *MemoryStore) getHashStringLocked(key string, hash string) (string, error) {
+	v, err := m.hashField(key, hash)
+	return v, opError("GetHashString", key, err)
+}
+
+//GetAllHashValues returns all the hash values for the key.
+func (m *MemoryStore) GetAllHashValues(key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return nil, opError("GetAllHashValues", key, err)
+	}
+	vals := make([]string, 0, len(h))
+	for _, v := range h {
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+//GetAllHashKeys returns all the hash keys for the key.
+func (m *MemoryStore) GetAllHashKeys(key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return nil, opError("GetAllHashKeys", key, err)
+	}
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+//SetHashStruct stores the exported fields of the struct in the hash.
+func (m *MemoryStore) SetHashStruct(key string, v interface{}) error {
+	fields, err := flattenHashStruct(v)
+	if err != nil {
+		return opError("SetHashStruct", key, err)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.hashValue(key, true)
+	if err != nil {
+		return opError("SetHashStruct", key, err)
+	}
+	for i := 0; i < len(fields); i += 2 {
+		h[fields[i].(string)] = fields[i+1].(string)
+	}
+	m.modified(key)
+	return nil
+}
+
+//GetHashStruct reads the hash into the fields of the struct pointed to by dst.
+func (m *MemoryStore) GetHashStruct(key string, dst interface{}) error {
+	rv, err := structPointer(dst)
+	if err != nil {
+		return opError("GetHashStruct", key, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return opError("GetHashStruct", key, err)
+	}
+	if len(h) == 0 {
+		return opError("GetHashStruct", key, ErrNotFound)
+	}
+	return opError("GetHashStruct", key, scanHashStruct(h, rv))
+}
+
+//HMSet sets the values of the hash keys.
+func (m *MemoryStore) HMSet(key string, values map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hmsetLocked(key, values)
+}
+
+//hmsetLocked is HMSet for callers holding the lock.
+func (m *MemoryStore) hmsetLocked(key string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	h, err := m.hashValue(key, true)
+	if err != nil {
+		return opError("HMSet", key, err)
+	}
+	for k, v := range values {
+		h[k] = formatValue(v)
+	}
+	m.modified(key)
+	return nil
+}
+
+//HMGet returns the string values of the hash keys. Missing hash keys are left out of the map.
+func (m *MemoryStore) HMGet(key string, hashes ...string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return nil, opError("HMGet", key, err)
+	}
+	vals := make(map[string]string, len(hashes))
+	for _, k := range hashes {
+		if v, ok := h[k]; ok {
+			vals[k] = v
+		}
+	}
+	return vals, nil
+}
+
+//HGetAll returns all the hash keys and values for the key.
+func (m *MemoryStore) HGetAll(key string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return nil, opError("HGetAll", key, err)
+	}
+	vals := make(map[string]string, len(h))
+	for k, v := range h {
+		vals[k] = v
+	}
+	return vals, nil
+}
+
+//SetExpiry sets the expiry for the specified key. A non positive expiry deletes the key.
+func (m *MemoryStore) SetExpiry(key string, seconds int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setExpiryLocked(key, seconds)
+}
+
+//setExpiryLocked is SetExpiry for callers holding the lock.
+func (m *MemoryStore) setExpiryLocked(key string, seconds int) error {
+	e := m.lookup(key)
+	if e == nil {
+		return nil
+	}
+	if seconds <= 0 {
+		m.deleteEntry(key)
+		return nil
+	}
+	e.expiresAt = time.Now().Add(time.Duration(seconds) * time.Second)
+	m.modified(key)
+	return nil
+}
+
+//incrementBy adds delta to the integer stored at key. The caller must hold the lock.
+func (m *MemoryStore) incrementBy(key string, delta int64) (int64, error) {
+	e := m.lookup(key)
+	if e == nil {
+		e = &memoryEntry{value: "0"}
+		m.storeEntry(key, e)
+	}
+	s, ok := e.value.(string)
+	if !ok {
+		return 0, ErrWrongType
+	}
+	n, err := addInt64(s, delta)
+	if err != nil {
+		return 0, err
+	}
+	e.value = strconv.FormatInt(n, 10)
+	m.modified(key)
+	return n, nil
+}
+
+//addInt64 parses the integer and adds delta, failing instead of overflowing.
+func addInt64(s string, delta int64) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return 0, ErrOverflow
+	}
+	return n + delta, nil
+}
+
+//Increment increments the value of key by 1.
+func (m *MemoryStore) Increment(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.incrementLocked(key)
+}
+
+//incrementLocked is Increment for callers holding the lock.
+func (m *MemoryStore) incrementLocked(key string) error {
+	_, err := m.incrementBy(key, 1)
+	return opError("Increment", key, err)
+}
+
+//Decrement decrements the value of key by 1.
+func (m *MemoryStore) Decrement(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.decrementLocked(key)
+}
+
+//decrementLocked is Decrement for callers holding the lock.
+func (m *MemoryStore) decrementLocked(key string) error {
+	_, err := m.incrementBy(key, -1)
+	return opError("Decrement", key, err)
+}
+
+//SetAdd adds a the value to a set.
+func (m *MemoryStore) SetAdd(key string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setAddLocked(key, value)
+}
+
+//setAddLocked is SetAdd for callers holding the lock.
+func (m *MemoryStore) setAddLocked(key string, value interface{}) error {
+	s, err := m.setValue(key, true)
+	if err != nil {
+		return opError("SetAdd", key, err)
+	}
+	s[formatValue(value)] = struct{}{}
+	m.modified(key)
+	return nil
+}
+
+//GetSetStringMembers returns the string members of a set.
+func (m *MemoryStore) GetSetStringMembers(key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.setValue(key, false)
+	if err != nil {
+		return nil, opError("GetSetStringMembers", key, err)
+	}
+	return setMembers(s), nil
+}
+
+//SetRemove removes the value from the set.
+func (m *MemoryStore) SetRemove(key string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setRemoveLocked(key, value)
+}
+
+//setRemoveLocked is SetRemove for callers holding the lock.
+func (m *MemoryStore) setRemoveLocked(key string, value interface{}) error {
+	s, err := m.setValue(key, false)
+	if err != nil {
+		return opError("SetRemove", key, err)
+	}
+	v := formatValue(value)
+	if _, ok := s[v]; !ok {
+		return nil
+	}
+	delete(s, v)
+	m.modified(key)
+	if len(s) == 0 {
+		m.deleteEntry(key)
+	}
+	return nil
+}
+
+//SetIsMember returns true if the value is a member of the set.
+func (m *MemoryStore) SetIsMember(key string, value interface{}) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setIsMemberLocked(key, value)
+}
+
+//setIsMemberLocked is SetIsMember for callers holding the lock.
+func (m *MemoryStore) setIsMemberLocked(key string, value interface{}) (bool, error) {
+	s, err := m.setValue(key, false)
+	if err != nil {
+		return false, opError("SetIsMember", key, err)
+	}
+	_, ok := s[formatValue(value)]
+	return ok, nil
+}
+
+//PushItemToList pushes an item to the list. Use atEnd to specify if the item should go at the end of the list instead of the front.
+func (m *MemoryStore) PushItemToList(key string, value interface{}, atEnd bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pushItemToListLocked(key, value, atEnd)
+}
+
+//pushItemToListLocked is PushItemToList for callers holding the lock.
+func (m *MemoryStore) pushItemToListLocked(key string, value interface{}, atEnd bool) error {
+	l, _, err := m.listValue(key)
+	if err != nil {
+		return opError("PushItemToList", key, err)
+	}
+	v := formatValue(value)
+	if atEnd {
+		l = append(l, v)
+	} else {
+		l = append([]string{v}, l...)
+	}
+	m.storeList(key, l)
+	return nil
+}
+
+//PopItemFromList pops an item from the front or the back of the list.
+func (m *MemoryStore) PopItemFromList(key string, dataType int, atEnd bool) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.popItemFromListLocked(key, dataType, atEnd)
+}
+
+//popItemFromListLocked is PopItemFromList for callers holding the lock.
+func (m *MemoryStore) popItemFromListLocked(key string, dataType int, atEnd bool) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("PopItemFromList", key, ErrInvalidDataType)
+	}
+
+	l, ok, err := m.listValue(key)
+	if err != nil {
+		return nil, opError("PopItemFromList", key, err)
+	}
+	if !ok {
+		return nil, opError("PopItemFromList", key, ErrNotFound)
+	}
+
+	var v string
+	if atEnd {
+		v, l = l[len(l)-1], l[:len(l)-1]
+	} else {
+		v, l = l[0], l[1:]
+	}
+	m.storeList(key, l)
+
+	item, err := convertItem(v, dataType)
+	return item, opError("PopItemFromList", key, err)
+}
+
+//ItemsFromList returns a list of items from the list from the start to end. The items are returned as a slice of
+//the data type, such as []int for DataTypeInt.
+func (m *MemoryStore) ItemsFromList(key string, dataType int, start, end int) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.itemsFromListLocked(key, dataType, start, end)
+}
+
+//itemsFromListLocked is ItemsFromList for callers holding the lock.
+func (m *MemoryStore) itemsFromListLocked(key string, dataType int, start, end int) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("ItemsFromList", key, ErrInvalidDataType)
+	}
+
+	l, _, err := m.listValue(key)
+	if err != nil {
+		return nil, opError("ItemsFromList", key, err)
+	}
+
+	converted, err := convertItems(listRange(l, start, end), dataType)
+	if err != nil {
+		return nil, opError("ItemsFromList", key, err)
+	}
+	return converted, nil
+}
+
+//RemoveItemFromList removes the item from the list with the count occurances.
+func (m *MemoryStore) RemoveItemFromList(key string, count int, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.removeItemFromListLocked(key, count, value)
+}
+
+//removeItemFromListLocked is RemoveItemFromList for callers holding the lock.
+func (m *MemoryStore) removeItemFromListLocked(key string, count int, value interface{}) error {
+	l, ok, err := m.listValue(key)
+	if err != nil {
+		return opError("RemoveItemFromList", key, err)
+	}
+	if !ok {
+		return nil
+	}
+
+	v := formatValue(value)
+	remaining := make([]string, 0, len(l))
+	if count >= 0 {
+		removed := 0
+		for _, item := range l {
+			if item == v && (count == 0 || removed < count) {
+				removed++
+				continue
+			}
+			remaining = append(remaining, item)
+		}
+	} else {
+		removed := 0
+		for i := len(l) - 1; i >= 0; i-- {
+			if l[i] == v && removed < -count {
+				removed++
+				continue
+			}
+			remaining = append([]string{l[i]}, remaining...)
+		}
+	}
+	m.storeList(key, remaining)
+	return nil
+}
+
+//LengthOfList returns the lenght of the list.
+func (m *MemoryStore) LengthOfList(key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lengthOfListLocked(key)
+}
+
+//lengthOfListLocked is LengthOfList for callers holding the lock.
+func (m *MemoryStore) lengthOfListLocked(key string) (int, error) {
+	l, _, err := m.listValue(key)
+	return len(l), opError("LengthOfList", key, err)
+}
+
+//ClearDataStore clears up all the keys in the memory store.
+func (m *MemoryStore) ClearDataStore() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = make(map[string]*memoryEntry)
+	for _, w := range m.watched {
+		w.version++
+	}
+	return nil
+}
+
+//DeleteByPattern deletes the keys matching the glob style pattern and returns the number of deleted keys. An empty
+//pattern is refused, use ClearDataStore to delete every key.
+func (m *MemoryStore) DeleteByPattern(pattern string) (int, error) {
+	if pattern == "" {
+		return 0, opError("DeleteByPattern", "", ErrEmptyPattern)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for k := range m.data {
+		if m.lookup(k) != nil && matchPattern(pattern, k) {
+			m.deleteEntry(k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+//listRange returns the items between start and end inclusive using redis index semantics.
+func listRange(l []string, start, end int) []string {
+	n := len(l)
+	if start < 0 {
+		start += n
+	}
+	if end < 0 {
+		end += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end >= n {
+		end = n - 1
+	}
+	if start > end || start >= n {
+		return nil
+	}
+	return l[start : end+1]
+}
+
+//formatValue formats a value the same way the redis store writes command arguments, with times as RFC3339Nano.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case nil:
+		return ""
+	default:
+		var buf bytes.Buffer
+		fmt.Fprint(&buf, v)
+		return buf.String()
+	}
+}
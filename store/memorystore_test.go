@@ -0,0 +1,224 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryGetSetDelString(t *testing.T) {
+	ms := NewMemoryStore()
+
+	_, err := ms.GetString("invalid_key")
+	assert.True(t, errors.Is(err, ErrNotFound), "Error should be nil reply fetching invalid key")
+
+	assert.Nil(t, ms.Set("key", "val"))
+
+	v, err := ms.GetString("key")
+	assert.Nil(t, err, "Error fetching string %v", err)
+	assert.Equal(t, "val", v, "Invalid fetched value")
+
+	_, err = ms.DeleteKey("key")
+	assert.Nil(t, err, "Error deleting key %v", err)
+
+	_, err = ms.GetString("key")
+	assert.True(t, errors.Is(err, ErrNotFound), "Deleted key should not be found")
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	ms := NewMemoryStore()
+
+	assert.Nil(t, ms.Set("key", "value"))
+	assert.Nil(t, ms.SetExpiry("key", 1))
+
+	time.Sleep(1100 * time.Millisecond)
+
+	_, err := ms.GetString("key")
+	assert.True(t, errors.Is(err, ErrNotFound), "Expired key should not be found")
+
+	assert.Nil(t, ms.Set("key", "value"))
+	assert.Nil(t, ms.SetExpiry("key", 0))
+
+	_, err = ms.GetString("key")
+	assert.True(t, errors.Is(err, ErrNotFound), "Key with non positive expiry should be deleted")
+}
+
+func TestMemoryIncrementInvalid(t *testing.T) {
+	ms := NewMemoryStore()
+
+	assert.Nil(t, ms.Set("key", "abc"))
+	assert.True(t, errors.Is(ms.Increment("key"), ErrNotInteger), "Incrementing non numeric value should fail")
+
+	_, err := ms.GetInt64("key")
+	assert.NotNil(t, err, "Fetching non numeric value as int64 should fail")
+}
+
+func TestMemoryWrongType(t *testing.T) {
+	ms := NewMemoryStore()
+
+	assert.Nil(t, ms.PushItemToList("key", "a", true))
+
+	_, err := ms.GetString("key")
+	assert.True(t, errors.Is(err, ErrWrongType), "Fetching a list as string should fail")
+	assert.True(t, errors.Is(ms.SetHash("key", "h", "v"), ErrWrongType), "Setting hash on a list should fail")
+	assert.True(t, errors.Is(ms.SetAdd("key", "v"), ErrWrongType), "Adding to set on a list should fail")
+	assert.True(t, errors.Is(ms.Increment("key"), ErrWrongType), "Incrementing a list should fail")
+}
+
+func TestMemoryListRemoveCount(t *testing.T) {
+	ms := NewMemoryStore()
+
+	for _, i := range []string{"a", "b", "a", "c", "a"} {
+		assert.Nil(t, ms.PushItemToList("key", i, true))
+	}
+
+	assert.Nil(t, ms.RemoveItemFromList("key", -2, "a"))
+
+	items, err := ms.ItemsFromList("key", DataTypeString, 0, -1)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, []string{"a", "b", "c"}, items, "Invalid items after removing from the tail")
+
+	assert.Nil(t, ms.RemoveItemFromList("key", 1, "a"))
+
+	items, err = ms.ItemsFromList("key", DataTypeString, 0, -1)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, []string{"b", "c"}, items, "Invalid items after removing from the head")
+}
+
+func TestMemoryPopEmptyList(t *testing.T) {
+	ms := NewMemoryStore()
+
+	_, err := ms.PopItemFromList("key", DataTypeString, true)
+	assert.True(t, errors.Is(err, ErrNotFound), "Popping an empty list should return a nil reply")
+
+	assert.Nil(t, ms.PushItemToList("key", true, false))
+
+	i, err := ms.PopItemFromList("key", DataTypeBool, false)
+	assert.Nil(t, err, "Error popping item from list %v", err)
+	assert.Equal(t, true, i, "Popped item should be true")
+
+	l, err := ms.LengthOfList("key")
+	assert.Nil(t, err, "Error retrieving length of list %v", err)
+	assert.Equal(t, 0, l, "List should be empty")
+}
+
+func TestMemoryConcurrentIncrement(t *testing.T) {
+	ms := NewMemoryStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ms.Increment("key")
+		}()
+	}
+	wg.Wait()
+
+	v, err := ms.GetInt64("key")
+	assert.Nil(t, err, "Error getting incremented key")
+	assert.Equal(t, int64(50), v, "Increment count is invalid")
+}
+
+func TestMemoryTransactionUnwatches(t *testing.T) {
+	ms := NewMemoryStore()
+
+	err := ms.Transaction([]string{"a", "b", "a"}, func(tx Tx) error {
+		assert.Equal(t, 2, len(ms.watched), "Watched keys should be tracked during the transaction")
+		return tx.Set("a", 1)
+	})
+	assert.Nil(t, err, "Error running transaction %v", err)
+	assert.Equal(t, 0, len(ms.watched), "Watched keys should not be tracked after the transaction")
+
+	ms.Set("a", 1)
+	assert.Equal(t, 0, len(ms.watched), "Writes to unwatched keys should not be tracked")
+}
+
+func TestMemoryHScanPages(t *testing.T) {
+	ms := NewMemoryStore()
+	for i := 0; i < 25; i++ {
+		ms.SetHash("hash", fmt.Sprintf("f%02d", i), i)
+	}
+
+	it := ms.HScan("hash", "", 10)
+	assert.True(t, it.Next(), "Hash should have fields")
+	assert.Equal(t, 18, len(it.page), "Page should hold count fields and their values")
+
+	ms.DeleteHash("hash", "f15")
+	ms.SetHash("hash", "f99", 99)
+	fields := []string{it.Val()}
+	for it.Next() {
+		fields = append(fields, it.Val())
+	}
+	assert.Nil(t, it.Err(), "Error scanning hash %v", it.Err())
+	assert.Equal(t, 24, len(fields), "Deleted and added fields should not be returned")
+	assert.NotContains(t, fields, "f15", "Deleted field should be skipped")
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"", "anything", true},
+		{"*", "", true},
+		{"user:*", "user:1", true},
+		{"user:*", "user", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h*llo", "heeeello", true},
+		{"h*llo", "hello world", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[c-a]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"a/*", "a/b/c", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, matchPattern(tt.pattern, tt.s), "Invalid match of %q against %q", tt.s, tt.pattern)
+	}
+}
+
+func TestSkiplist(t *testing.T) {
+	z := newSortedSet()
+	for i := 0; i < 1000; i++ {
+		z.set(strconv.Itoa(rand.Intn(300)), float64(rand.Intn(50)))
+		if i%3 == 0 {
+			z.remove(strconv.Itoa(rand.Intn(300)))
+		}
+	}
+
+	expected := make([]ZMember, 0, len(z.scores))
+	for m, score := range z.scores {
+		expected = append(expected, ZMember{Member: m, Score: score})
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		a, b := expected[i], expected[j]
+		return a.Score < b.Score || a.Score == b.Score && a.Member < b.Member
+	})
+
+	assert.Equal(t, len(expected), z.list.length, "Skiplist length should match the scores")
+	i := 0
+	for n := z.list.head.next(); n != nil; n = n.next() {
+		assert.Equal(t, expected[i], ZMember{Member: n.member, Score: n.score}, "Skiplist should be ordered by score and member")
+		assert.Equal(t, i, z.list.rank(n.score, n.member), "Invalid rank of %s", n.member)
+		assert.Equal(t, n, z.list.byRank(i), "Invalid node at rank %d", i)
+		i++
+	}
+	assert.Equal(t, len(expected), i, "Skiplist should hold every member")
+	assert.Nil(t, z.list.byRank(i), "Rank past the end should have no node")
+	assert.Equal(t, -1, z.list.rank(100, "missing"), "Missing member should have no rank")
+}
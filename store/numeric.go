@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//IncrementBy adds n to the integer stored at key and returns the new value. A missing key starts at zero.
+func (r *Redis) IncrementBy(key string, n int64) (int64, error) {
+	return r.IncrementByContext(context.Background(), key, n)
+}
+
+//IncrementByContext adds n to the integer stored at key and returns the new value.
+func (r *Redis) IncrementByContext(ctx context.Context, key string, n int64) (int64, error) {
+	v, err := redis.Int64(r.do(ctx, "INCRBY", key, n))
+	return v, redisError("IncrementBy", key, err)
+}
+
+//DecrementBy subtracts n from the integer stored at key and returns the new value. A missing key starts at zero.
+func (r *Redis) DecrementBy(key string, n int64) (int64, error) {
+	return r.DecrementByContext(context.Background(), key, n)
+}
+
+//DecrementByContext subtracts n from the integer stored at key and returns the new value.
+func (r *Redis) DecrementByContext(ctx context.Context, key string, n int64) (int64, error) {
+	v, err := redis.Int64(r.do(ctx, "DECRBY", key, n))
+	return v, redisError("DecrementBy", key, err)
+}
+
+//IncrementByFloat adds n to the number stored at key and returns the new value. A missing key starts at zero.
+func (r *Redis) IncrementByFloat(key string, n float64) (float64, error) {
+	return r.IncrementByFloatContext(context.Background(), key, n)
+}
+
+//IncrementByFloatContext adds n to the number stored at key and returns the new value.
+func (r *Redis) IncrementByFloatContext(ctx context.Context, key string, n float64) (float64, error) {
+	v, err := redis.Float64(r.do(ctx, "INCRBYFLOAT", key, n))
+	return v, redisError("IncrementByFloat", key, err)
+}
+
+//HashIncrementBy adds n to the integer stored in the hash key and returns the new value. A missing hash key starts
+//at zero.
+func (r *Redis) HashIncrementBy(key string, hash string, n int64) (int64, error) {
+	return r.HashIncrementByContext(context.Background(), key, hash, n)
+}
+
+//HashIncrementByContext adds n to the integer stored in the hash key and returns the new value.
+func (r *Redis) HashIncrementByContext(ctx context.Context, key string, hash string, n int64) (int64, error) {
+	v, err := redis.Int64(r.do(ctx, "HINCRBY", key, hash, n))
+	return v, redisError("HashIncrementBy", key, err)
+}
+
+//IncrementBy adds n to the integer stored at key and returns the new value. A missing key starts at zero.
+func (m *MemoryStore) IncrementBy(key string, n int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.incrementByLocked(key, n)
+}
+
+//incrementByLocked is IncrementBy for callers holding the lock.
+func (m *MemoryStore) incrementByLocked(key string, n int64) (int64, error) {
+	v, err := m.incrementBy(key, n)
+	return v, opError("IncrementBy", key, err)
+}
+
+//DecrementBy subtracts n from the integer stored at key and returns the new value. A missing key starts at zero.
+func (m *MemoryStore) DecrementBy(key string, n int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.decrementByLocked(key, n)
+}
+
+//decrementByLocked is DecrementBy for callers holding the lock.
+func (m *MemoryStore) decrementByLocked(key string, n int64) (int64, error) {
+	if n == math.MinInt64 {
+		return 0, opError("DecrementBy", key, ErrOverflow)
+	}
+	v, err := m.incrementBy(key, -n)
+	return v, opError("DecrementBy", key, err)
+}
+
+//IncrementByFloat adds n to the number stored at key and returns the new value. A missing key starts at zero.
+func (m *MemoryStore) IncrementByFloat(key string, n float64) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.incrementByFloatLocked(key, n)
+}
+
+//incrementByFloatLocked is IncrementByFloat for callers holding the lock.
+func (m *MemoryStore) incrementByFloatLocked(key string, n float64) (float64, error) {
+	s, ok, err := m.stringValue(key)
+	if err != nil {
+		return 0, opError("IncrementByFloat", key, err)
+	}
+	v := 0.0
+	if ok {
+		if v, err = strconv.ParseFloat(s, 64); err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, opError("IncrementByFloat", key, ErrNotFloat)
+		}
+	}
+	v += n
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, opError("IncrementByFloat", key, ErrNotFinite)
+	}
+
+	if e := m.lookup(key); e != nil {
+		e.value = strconv.FormatFloat(v, 'f', -1, 64)
+		m.modified(key)
+	} else {
+		m.storeEntry(key, &memoryEntry{value: strconv.FormatFloat(v, 'f', -1, 64)})
+	}
+	return v, nil
+}
+
+//HashIncrementBy adds n to the integer stored in the hash key and returns the new value. A missing hash key starts
+//at zero.
+func (m *MemoryStore) HashIncrementBy(key string, hash string, n int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hashIncrementByLocked(key, hash, n)
+}
+
+//hashIncrementByLocked is HashIncrementBy for callers holding the lock.
+func (m *MemoryStore) hashIncrementByLocked(key string, hash string, n int64) (int64, error) {
+	h, err := m.hashValue(key, false)
+	if err != nil {
+		return 0, opError("HashIncrementBy", key, err)
+	}
+	s, ok := h[hash]
+	if !ok {
+		s = "0"
+	}
+	v, err := addInt64(s, n)
+	if err != nil {
+		return 0, opError("HashIncrementBy", key, err)
+	}
+
+	if h == nil {
+		h, _ = m.hashValue(key, true)
+	}
+	h[hash] = strconv.FormatInt(v, 10)
+	m.modified(key)
+	return v, nil
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//Pipeliner is implemented by stores that can send a batch of commands in a single round trip.
+type Pipeliner interface {
+	Pipeline() *Pipeline
+}
+
+//PipelineResult is the result of a single queued command. Value holds the fetched value for commands that read data
+//and is nil otherwise.
+type PipelineResult struct {
+	Value interface{}
+	Err   error
+}
+
+//Pipeline queues commands and sends them together when Exec is called. A pipeline is not safe for concurrent use and
+//the commands are not run atomically, other clients may run commands in between.
+type Pipeline struct {
+	cmds []command
+	exec func(ctx context.Context, cmds []command) ([]PipelineResult, error)
+}
+
+//NewPipeline returns a pipeline for the store. Stores implementing Pipeliner send the commands in a single round trip,
+//other stores run them one after the other when Exec is called.
+func NewPipeline(s Store) *Pipeline {
+	if p, ok := s.(Pipeliner); ok {
+		return p.Pipeline()
+	}
+	return &Pipeline{
+		exec: func(ctx context.Context, cmds []command) ([]PipelineResult, error) {
+			results := make([]PipelineResult, len(cmds))
+			for i, cmd := range cmds {
+				if err := ctx.Err(); err != nil {
+					return nil, opError("Pipeline", "", err)
+				}
+				results[i].Value, results[i].Err = cmd.run(s)
+			}
+			return results, nil
+		},
+	}
+}
+
+//Len returns the number of queued commands.
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+//Exec sends the queued commands and returns their results in the order they were queued. Failures of single commands
+//are reported in the results, the error is only set when the pipeline as a whole could not be run. The queue is empty
+//once Exec returns.
+func (p *Pipeline) Exec() ([]PipelineResult, error) {
+	return p.ExecContext(context.Background())
+}
+
+//ExecContext sends the queued commands, aborting when the context is done.
+func (p *Pipeline) ExecContext(ctx context.Context) ([]PipelineResult, error) {
+	cmds := p.cmds
+	p.cmds = nil
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	return p.exec(ctx, cmds)
+}
+
+//add queues a command.
+func (p *Pipeline) add(cmd command) {
+	p.cmds = append(p.cmds, cmd)
+}
+
+//DeleteKey queues deleting the keys. The result value is the number of keys deleted as an int.
+func (p *Pipeline) DeleteKey(keys ...string) {
+	p.add(deleteKeyCommand(keys))
+}
+
+//GetString queues fetching the string stored at key. The result value is a string.
+func (p *Pipeline) GetString(key string) {
+	p.add(getStringCommand(key))
+}
+
+//GetInt64 queues fetching the int64 stored at key. The result value is an int64.
+func (p *Pipeline) GetInt64(key string) {
+	p.add(getInt64Command(key))
+}
+
+//Set queues setting the value for the key.
+func (p *Pipeline) Set(key string, value interface{}) {
+	p.add(setCommand(key, value))
+}
+
+//SetHash queues setting the value of the hash key.
+func (p *Pipeline) SetHash(key string, hash string, value interface{}) {
+	p.add(setHashCommand(key, hash, value))
+}
+
+//DeleteHash queues deleting the hash key.
+func (p *Pipeline) DeleteHash(key string, hash string) {
+	p.add(deleteHashCommand(key, hash))
+}
+
+//GetHashString queues fetching the string value of the hash key. The result value is a string.
+func (p *Pipeline) GetHashString(key string, hash string) {
+	p.add(getHashStringCommand(key, hash))
+}
+
+//SetExpiry queues setting the expiry of the key.
+func (p *Pipeline) SetExpiry(key string, seconds int) {
+	p.add(setExpiryCommand(key, seconds))
+}
+
+//Increment queues incrementing the value of key by 1.
+func (p *Pipeline) Increment(key string) {
+	p.add(incrementCommand(key))
+}
+
+//Decrement queues decrementing the value of key by 1.
+func (p *Pipeline) Decrement(key string) {
+	p.add(decrementCommand(key))
+}
+
+//SetAdd queues adding the value to a set.
+func (p *Pipeline) SetAdd(key string, value interface{}) {
+	p.add(setAddCommand(key, value))
+}
+
+//SetRemove queues removing the value from a set.
+func (p *Pipeline) SetRemove(key string, value interface{}) {
+	p.add(setRemoveCommand(key, value))
+}
+
+//SetIsMember queues checking if the value is a member of the set. The result value is a bool.
+func (p *Pipeline) SetIsMember(key string, value interface{}) {
+	p.add(setIsMemberCommand(key, value))
+}
+
+//PushItemToList queues pushing an item to the front or the end of the list.
+func (p *Pipeline) PushItemToList(key string, value interface{}, atEnd bool) {
+	p.add(pushItemToListCommand(key, value, atEnd))
+}
+
+//RemoveItemFromList queues removing count occurrences of the item from the list.
+func (p *Pipeline) RemoveItemFromList(key string, count int, value interface{}) {
+	p.add(removeItemFromListCommand(key, count, value))
+}
+
+//LengthOfList queues fetching the length of the list. The result value is an int.
+func (p *Pipeline) LengthOfList(key string) {
+	p.add(lengthOfListCommand(key))
+}
+
+//Pipeline returns a pipeline that sends the queued commands on a single pooled connection in one round trip.
+func (r *Redis) Pipeline() *Pipeline {
+	return &Pipeline{exec: r.execPipeline}
+}
+
+//execPipeline sends the commands and reads all the replies with a single flush.
+func (r *Redis) execPipeline(ctx context.Context, cmds []command) ([]PipelineResult, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, opError("Pipeline", "", err)
+	}
+	defer conn.Close()
+
+	for _, cmd := range cmds {
+		if err := conn.Send(cmd.name, cmd.args...); err != nil {
+			return nil, redisError("Pipeline", "", err)
+		}
+	}
+
+	//Do with an empty command flushes the queued commands and receives all the pending replies.
+	replies, err := redis.Values(conn.Do("", contextArg{ctx}))
+	if err != nil {
+		return nil, redisError("Pipeline", "", err)
+	}
+
+	results := make([]PipelineResult, len(cmds))
+	for i, cmd := range cmds {
+		var replyErr error
+		if re, ok := replies[i].(redis.Error); ok {
+			replyErr = re
+		}
+		v, err := cmd.reply(replies[i], replyErr)
+		if err != nil {
+			results[i].Err = redisError(cmd.op, cmd.key, err)
+			continue
+		}
+		results[i].Value = v
+	}
+	return results, nil
+}
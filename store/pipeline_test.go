@@ -0,0 +1,42 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/awkhan/go-store/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisPipelineContext(t *testing.T) {
+	rs.ClearDataStore()
+
+	p := rs.Pipeline()
+	p.Set("key", "value")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := p.ExecContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled), "Cancelled context should fail the pipeline")
+
+	_, err = rs.GetString("key")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Commands of a cancelled pipeline should not run")
+}
+
+func TestMemoryPipelineContext(t *testing.T) {
+	ms := store.NewMemoryStore()
+
+	p := store.NewPipeline(ms)
+	p.Set("key", "value")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := p.ExecContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled), "Cancelled context should fail the pipeline")
+
+	_, err = ms.GetString("key")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Commands of a cancelled pipeline should not run")
+}
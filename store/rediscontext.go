@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//contextArg carries a context through the pool to the connection. The pooled connections handed out by redigo can't
+//be unwrapped, so the context is passed as a trailing argument that contextConn strips before sending the command.
+type contextArg struct {
+	ctx context.Context
+}
+
+//splitContext removes a trailing context argument, returning the context or nil if there is none.
+func splitContext(args []interface{}) (context.Context, []interface{}) {
+	if n := len(args); n > 0 {
+		if ca, ok := args[n-1].(contextArg); ok {
+			return ca.ctx, args[:n-1]
+		}
+	}
+	return nil, args
+}
+
+//cancelConn is a network connection that can be aborted. Once aborted its deadlines stay in the past so redigo can't
+//extend them while a blocked read or write unwinds.
+type cancelConn struct {
+	net.Conn
+
+	mu      sync.Mutex
+	aborted bool
+}
+
+//abort unblocks any pending read or write on the connection.
+func (c *cancelConn) abort() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aborted = true
+	c.Conn.SetDeadline(time.Unix(1, 0))
+}
+
+//SetDeadline sets the read and write deadlines unless the connection was aborted.
+func (c *cancelConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aborted {
+		return nil
+	}
+	return c.Conn.SetDeadline(t)
+}
+
+//SetReadDeadline sets the read deadline unless the connection was aborted.
+func (c *cancelConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aborted {
+		return nil
+	}
+	return c.Conn.SetReadDeadline(t)
+}
+
+//SetWriteDeadline sets the write deadline unless the connection was aborted.
+func (c *cancelConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aborted {
+		return nil
+	}
+	return c.Conn.SetWriteDeadline(t)
+}
+
+//contextConn is a redis connection whose commands can be aborted by a context passed as a contextArg.
+type contextConn struct {
+	redis.Conn
+	nc *cancelConn
+}
+
+//newContextConn wraps the network connection in a redis connection that supports cancellation.
+func newContextConn(netConn net.Conn, readTimeout, writeTimeout time.Duration) *contextConn {
+	nc := &cancelConn{Conn: netConn}
+	return &contextConn{
+		Conn: redis.NewConn(nc, readTimeout, writeTimeout),
+		nc:   nc,
+	}
+}
+
+//Do sends the command and waits for the reply. If the arguments end with a contextArg, the command is aborted when
+//the context is done and the connection is closed so the pool discards it.
+func (c *contextConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	ctx, args := splitContext(args)
+	args = formatArgs(args)
+	if ctx == nil || ctx.Done() == nil {
+		return c.Conn.Do(cmd, args...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	aborted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.nc.abort()
+			aborted <- true
+		case <-stop:
+			aborted <- false
+		}
+	}()
+
+	reply, err := c.Conn.Do(cmd, args...)
+	close(stop)
+	if <-aborted {
+		c.Conn.Close()
+		if err != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return reply, err
+}
+
+//Send writes the command to the output buffer of the connection.
+func (c *contextConn) Send(cmd string, args ...interface{}) error {
+	return c.Conn.Send(cmd, formatArgs(args)...)
+}
+
+//conn gets a connection from the pool, giving up when the context is done.
+func (r *Redis) conn(ctx context.Context) (redis.Conn, error) {
+	return poolConn(ctx, r.redis)
+}
+
+//poolConn gets a connection from p, giving up when the context is done.
+func poolConn(ctx context.Context, p *redis.Pool) (redis.Conn, error) {
+	if ctx.Done() == nil {
+		return p.Get(), nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan redis.Conn, 1)
+	go func() {
+		ch <- p.Get()
+	}()
+
+	select {
+	case c := <-ch:
+		return c, nil
+	case <-ctx.Done():
+		//Return the connection to the pool once the pool hands it out.
+		go func() {
+			(<-ch).Close()
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+//do runs a single command on a pooled connection, aborting it when the context is done.
+func (r *Redis) do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.Do(cmd, append(args, contextArg{ctx})...)
+}
@@ -0,0 +1,177 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/awkhan/go-store/store"
+	"github.com/awkhan/go-store/store/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSlowRedis(t *testing.T, opts store.RedisOptions) (*redistest.Server, *store.Redis) {
+	srv, err := redistest.NewServer()
+	if err != nil {
+		t.Fatalf("Unable to start fake redis server %v", err)
+	}
+
+	opts.Host, opts.Port = srv.Host(), srv.Port()
+	r, err := store.NewRedisStoreWithOptions(opts)
+	if err != nil {
+		t.Fatalf("Unable to create store %v", err)
+	}
+	return srv, r
+}
+
+func TestRedisContextAbortsCommand(t *testing.T) {
+	srv, r := newSlowRedis(t, store.RedisOptions{MaxIdle: 1})
+	defer srv.Close()
+	defer r.Close()
+
+	assert.Nil(t, r.SetContext(context.Background(), "key", "value"), "Error setting value")
+
+	srv.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := r.GetStringContext(ctx, "key")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "Command should be aborted by the deadline")
+	assert.True(t, time.Since(start) < 500*time.Millisecond, "Command should not wait for the reply")
+
+	srv.SetLatency(0)
+
+	v, err := r.GetStringContext(context.Background(), "key")
+	assert.Nil(t, err, "Aborted connection should not be reused %v", err)
+	assert.Equal(t, "value", v, "Invalid fetched value")
+}
+
+func TestRedisContextAbortsPoolWait(t *testing.T) {
+	srv, r := newSlowRedis(t, store.RedisOptions{MaxIdle: 1, MaxActive: 1, Wait: true})
+	defer srv.Close()
+	defer r.Close()
+
+	srv.SetLatency(500 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- r.Set("key", "value")
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := r.GetStringContext(ctx, "key")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "Waiting for a connection should be aborted by the deadline")
+	assert.True(t, time.Since(start) < 300*time.Millisecond, "Command should not wait for the pool")
+
+	assert.Nil(t, <-done, "Error setting value")
+}
+
+func TestRedisContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.True(t, errors.Is(rs.SetContext(ctx, "key", "value"), context.Canceled), "Cancelled context should fail")
+}
+
+func TestContextAdapter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := store.NewContextAdapter(ctx, rs)
+
+	s.ClearDataStore()
+	assert.Nil(t, s.Set("key", "value"), "Error setting value")
+
+	v, err := s.GetString("key")
+	assert.Nil(t, err, "Error fetching value %v", err)
+	assert.Equal(t, "value", v, "Invalid fetched value")
+
+	cancel()
+
+	_, err = s.GetString("key")
+	assert.True(t, errors.Is(err, context.Canceled), "Adapter should use the supplied context")
+}
+
+func TestRedisContextAbortsBlockingPop(t *testing.T) {
+	srv, r := newSlowRedis(t, store.RedisOptions{MaxIdle: 1, MaxActive: 1, ReadTimeout: 50 * time.Millisecond})
+	defer srv.Close()
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		_, _, err := r.BlockingPopContext(ctx, []string{"key"}, 0, false)
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Nil(t, r.Set("other", "value"), "Blocked pop should not hold a pooled connection")
+
+	start := time.Now()
+	assert.True(t, errors.Is(<-done, context.DeadlineExceeded), "Blocked pop should be aborted by the deadline and not the read timeout")
+	assert.True(t, time.Since(start) < 500*time.Millisecond, "Blocked pop should not wait for an item")
+}
+
+//countingProxy forwards connections to addr and counts how many were accepted.
+func countingProxy(t *testing.T, addr string) (net.Listener, *int32) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen %v", err)
+	}
+
+	var accepted int32
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepted, 1)
+			go func() {
+				defer c.Close()
+				upstream, err := net.Dial("tcp", addr)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				go io.Copy(upstream, c)
+				io.Copy(c, upstream)
+			}()
+		}
+	}()
+	return l, &accepted
+}
+
+func TestRedisBlockingReusesConnections(t *testing.T) {
+	srv, err := redistest.NewServer()
+	if err != nil {
+		t.Fatalf("Unable to start fake redis server %v", err)
+	}
+	defer srv.Close()
+
+	l, accepted := countingProxy(t, srv.Addr())
+	defer l.Close()
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	r, err := store.NewRedisStoreWithOptions(store.RedisOptions{Host: host, Port: port, MaxIdle: 1})
+	if err != nil {
+		t.Fatalf("Unable to create store %v", err)
+	}
+	defer r.Close()
+
+	for i := 0; i < 3; i++ {
+		_, _, err := r.BlockingPop([]string{"key"}, 10*time.Millisecond, false)
+		assert.True(t, errors.Is(err, store.ErrNotFound), "Blocked pop should time out")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(accepted), "Blocked pops should reuse an idle connection")
+}
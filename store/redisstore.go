@@ -1,227 +1,506 @@
 package store
 
 import (
-	"errors"
+	"context"
+	"sync/atomic"
+	"time"
 
-	"github.com/awkhan/go-utility/configuration"
 	"github.com/garyburd/redigo/redis"
 )
 
 //Redis provides an interface to redis.
 type Redis struct {
+	//TxRetries is the number of times a transaction is retried after a conflict. DefaultTxRetries is used when it is
+	//not positive.
+	TxRetries int
+
 	redis *redis.Pool
+	//blocking is the pool of connections without a read timeout that blocking commands run on.
+	blocking *redis.Pool
+	//flushLocked is set to 1 while ClearDataStore is refused.
+	flushLocked int32
 }
 
-//NewRedisStore creates a new redis store with the supplied pool.
+//NewRedisStore creates a new redis store with the supplied pool settings. For compatibility the idle timeout is in
+//nanoseconds and the reply to AUTH is ignored, as they always were. Use NewRedisStoreWithOptions for more control over
+//the pool and for dials that fail when AUTH is rejected.
 func NewRedisStore(maxIdle int, idleTimeout int, host, port, password string) *Redis {
-	return &Redis{
-		redis: configuration.GetRedisPool(maxIdle, idleTimeout, host, port, password),
+	return newRedis(RedisOptions{
+		Host:            host,
+		Port:            port,
+		Password:        password,
+		MaxIdle:         maxIdle,
+		IdleTimeout:     time.Duration(idleTimeout),
+		ignoreAuthError: true,
+	}, nil)
+}
+
+//Close closes the connection pools of the store.
+func (r *Redis) Close() error {
+	err := r.redis.Close()
+	if berr := r.blocking.Close(); err == nil {
+		err = berr
 	}
+	return err
 }
 
-//DeleteKey deletes the key from redis.
-func (r *Redis) DeleteKey(key string) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("DEL", key)
-	return e
+//DeleteKey deletes the keys from redis and returns the number of keys deleted. Use Unlink to free large values in the
+//background.
+func (r *Redis) DeleteKey(keys ...string) (int, error) {
+	return r.DeleteKeyContext(context.Background(), keys...)
+}
+
+//DeleteKeyContext deletes the keys from redis and returns the number of keys deleted.
+func (r *Redis) DeleteKeyContext(ctx context.Context, keys ...string) (int, error) {
+	return r.countKeys(ctx, "DeleteKey", "DEL", keys)
 }
 
 //GetString retrieves the string data stored in redis.
 func (r *Redis) GetString(key string) (string, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
-	res, e := redis.String(conn.Do("GET", key))
-	return res, e
+	return r.GetStringContext(context.Background(), key)
+}
+
+//GetStringContext retrieves the string data stored in redis.
+func (r *Redis) GetStringContext(ctx context.Context, key string) (string, error) {
+	v, err := redis.String(r.do(ctx, "GET", key))
+	return v, redisError("GetString", key, err)
 }
 
 //GetInt64 retrieves the int64 data stored in redis.
 func (r *Redis) GetInt64(key string) (int64, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
-	res, e := redis.Int64(conn.Do("GET", key))
-	return res, e
+	return r.GetInt64Context(context.Background(), key)
+}
+
+//GetInt64Context retrieves the int64 data stored in redis.
+func (r *Redis) GetInt64Context(ctx context.Context, key string) (int64, error) {
+	v, err := redis.Int64(r.do(ctx, "GET", key))
+	return v, redisError("GetInt64", key, err)
+}
+
+//MGetStrings retrieves the string data stored at the keys with a single MGET. Missing keys and keys holding other
+//types are left out of the map.
+func (r *Redis) MGetStrings(keys ...string) (map[string]string, error) {
+	return r.MGetStringsContext(context.Background(), keys...)
+}
+
+//MGetStringsContext retrieves the string data stored at the keys with a single MGET.
+func (r *Redis) MGetStringsContext(ctx context.Context, keys ...string) (map[string]string, error) {
+	m := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return m, nil
+	}
+
+	vals, err := redis.Values(r.do(ctx, "MGET", redis.Args{}.AddFlat(keys)...))
+	if err != nil {
+		return nil, redisError("MGetStrings", "", err)
+	}
+	return m, redisError("MGetStrings", "", stringMap(m, keys, vals))
 }
 
 //Set sets the value for the specified key.
 func (r *Redis) Set(key string, value interface{}) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("SET", key, value)
-	return e
+	return r.SetContext(context.Background(), key, value)
+}
+
+//SetContext sets the value for the specified key.
+func (r *Redis) SetContext(ctx context.Context, key string, value interface{}) error {
+	_, err := r.do(ctx, "SET", key, value)
+	return redisError("Set", key, err)
+}
+
+//MSet sets the values for the keys with a single MSET.
+func (r *Redis) MSet(values map[string]interface{}) error {
+	return r.MSetContext(context.Background(), values)
+}
+
+//MSetContext sets the values for the keys with a single MSET.
+func (r *Redis) MSetContext(ctx context.Context, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := r.do(ctx, "MSET", redis.Args{}.AddFlat(values)...)
+	return redisError("MSet", "", err)
 }
 
 //SetHash sets the value for the specific hash key.
 func (r *Redis) SetHash(key string, hash string, value interface{}) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("HSET", key, hash, value)
-	return e
+	return r.SetHashContext(context.Background(), key, hash, value)
+}
+
+//SetHashContext sets the value for the specific hash key.
+func (r *Redis) SetHashContext(ctx context.Context, key string, hash string, value interface{}) error {
+	_, err := r.do(ctx, "HSET", key, hash, value)
+	return redisError("SetHash", key, err)
 }
 
 //DeleteHash deletes the hash value for the specific key.
 func (r *Redis) DeleteHash(key string, hash string) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("HDEL", key, hash)
-	return e
+	return r.DeleteHashContext(context.Background(), key, hash)
+}
+
+//DeleteHashContext deletes the hash value for the specific key.
+func (r *Redis) DeleteHashContext(ctx context.Context, key string, hash string) error {
+	_, err := r.do(ctx, "HDEL", key, hash)
+	return redisError("DeleteHash", key, err)
 }
 
 //GetHashString returns the string value of the hash.
 func (r *Redis) GetHashString(key string, hash string) (string, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
-	val, e := redis.String(conn.Do("HGET", key, hash))
-	return val, e
+	return r.GetHashStringContext(context.Background(), key, hash)
+}
+
+//GetHashStringContext returns the string value of the hash.
+func (r *Redis) GetHashStringContext(ctx context.Context, key string, hash string) (string, error) {
+	v, err := redis.String(r.do(ctx, "HGET", key, hash))
+	return v, redisError("GetHashString", key, err)
 }
 
 //GetAllHashValues returns all the hash values for the key.
 func (r *Redis) GetAllHashValues(key string) ([]string, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
-	val, e := redis.Strings(conn.Do("HVALS", key))
-	return val, e
+	return r.GetAllHashValuesContext(context.Background(), key)
+}
+
+//GetAllHashValuesContext returns all the hash values for the key.
+func (r *Redis) GetAllHashValuesContext(ctx context.Context, key string) ([]string, error) {
+	v, err := redis.Strings(r.do(ctx, "HVALS", key))
+	return v, redisError("GetAllHashValues", key, err)
 }
 
 //GetAllHashKeys returns all the hash keys for the key.
 func (r *Redis) GetAllHashKeys(key string) ([]string, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
-	val, e := redis.Strings(conn.Do("HKEYS", key))
-	return val, e
+	return r.GetAllHashKeysContext(context.Background(), key)
+}
+
+//GetAllHashKeysContext returns all the hash keys for the key.
+func (r *Redis) GetAllHashKeysContext(ctx context.Context, key string) ([]string, error) {
+	v, err := redis.Strings(r.do(ctx, "HKEYS", key))
+	return v, redisError("GetAllHashKeys", key, err)
+}
+
+//SetHashStruct stores the exported fields of the struct in the hash with a single HMSET. Field names can be changed
+//with a `store:"name,omitempty"` tag and fields tagged `store:"-"` are skipped.
+func (r *Redis) SetHashStruct(key string, v interface{}) error {
+	return r.SetHashStructContext(context.Background(), key, v)
+}
+
+//SetHashStructContext stores the exported fields of the struct in the hash with a single HMSET.
+func (r *Redis) SetHashStructContext(ctx context.Context, key string, v interface{}) error {
+	fields, err := flattenHashStruct(v)
+	if err != nil {
+		return opError("SetHashStruct", key, err)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	_, err = r.do(ctx, "HMSET", redis.Args{key}.Add(fields...)...)
+	return redisError("SetHashStruct", key, err)
+}
+
+//GetHashStruct reads the hash into the fields of the struct pointed to by dst with a single HGETALL.
+func (r *Redis) GetHashStruct(key string, dst interface{}) error {
+	return r.GetHashStructContext(context.Background(), key, dst)
+}
+
+//GetHashStructContext reads the hash into the fields of the struct pointed to by dst with a single HGETALL.
+func (r *Redis) GetHashStructContext(ctx context.Context, key string, dst interface{}) error {
+	rv, err := structPointer(dst)
+	if err != nil {
+		return opError("GetHashStruct", key, err)
+	}
+
+	h, err := redis.StringMap(r.do(ctx, "HGETALL", key))
+	if err != nil {
+		return redisError("GetHashStruct", key, err)
+	}
+	if len(h) == 0 {
+		return opError("GetHashStruct", key, ErrNotFound)
+	}
+	return opError("GetHashStruct", key, scanHashStruct(h, rv))
+}
+
+//HMSet sets the values of the hash keys with a single HMSET.
+func (r *Redis) HMSet(key string, values map[string]interface{}) error {
+	return r.HMSetContext(context.Background(), key, values)
+}
+
+//HMSetContext sets the values of the hash keys with a single HMSET.
+func (r *Redis) HMSetContext(ctx context.Context, key string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := r.do(ctx, "HMSET", redis.Args{key}.AddFlat(values)...)
+	return redisError("HMSet", key, err)
+}
+
+//HMGet returns the string values of the hash keys with a single HMGET. Missing hash keys are left out of the map.
+func (r *Redis) HMGet(key string, hashes ...string) (map[string]string, error) {
+	return r.HMGetContext(context.Background(), key, hashes...)
+}
+
+//HMGetContext returns the string values of the hash keys with a single HMGET.
+func (r *Redis) HMGetContext(ctx context.Context, key string, hashes ...string) (map[string]string, error) {
+	m := make(map[string]string, len(hashes))
+	if len(hashes) == 0 {
+		return m, nil
+	}
+
+	vals, err := redis.Values(r.do(ctx, "HMGET", redis.Args{key}.AddFlat(hashes)...))
+	if err != nil {
+		return nil, redisError("HMGet", key, err)
+	}
+	return m, redisError("HMGet", key, stringMap(m, hashes, vals))
+}
+
+//HGetAll returns all the hash keys and values for the key.
+func (r *Redis) HGetAll(key string) (map[string]string, error) {
+	return r.HGetAllContext(context.Background(), key)
+}
+
+//HGetAllContext returns all the hash keys and values for the key.
+func (r *Redis) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	v, err := redis.StringMap(r.do(ctx, "HGETALL", key))
+	return v, redisError("HGetAll", key, err)
 }
 
 //SetExpiry sets the expiry for the specified key.
 func (r *Redis) SetExpiry(key string, seconds int) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("EXPIRE", key, seconds)
-	return e
+	return r.SetExpiryContext(context.Background(), key, seconds)
+}
+
+//SetExpiryContext sets the expiry for the specified key.
+func (r *Redis) SetExpiryContext(ctx context.Context, key string, seconds int) error {
+	_, err := r.do(ctx, "EXPIRE", key, seconds)
+	return redisError("SetExpiry", key, err)
 }
 
 //Increment increments the value of key by 1.
 func (r *Redis) Increment(key string) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("INCR", key)
-	return e
+	return r.IncrementContext(context.Background(), key)
+}
+
+//IncrementContext increments the value of key by 1.
+func (r *Redis) IncrementContext(ctx context.Context, key string) error {
+	_, err := r.do(ctx, "INCR", key)
+	return redisError("Increment", key, err)
 }
 
 //Decrement decrements the value of key by 1.
 func (r *Redis) Decrement(key string) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("DECR", key)
-	return e
+	return r.DecrementContext(context.Background(), key)
+}
+
+//DecrementContext decrements the value of key by 1.
+func (r *Redis) DecrementContext(ctx context.Context, key string) error {
+	_, err := r.do(ctx, "DECR", key)
+	return redisError("Decrement", key, err)
 }
 
 //SetAdd adds a the value to a set.
 func (r *Redis) SetAdd(key string, value interface{}) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("SADD", key, value)
-	return e
+	return r.SetAddContext(context.Background(), key, value)
+}
+
+//SetAddContext adds a the value to a set.
+func (r *Redis) SetAddContext(ctx context.Context, key string, value interface{}) error {
+	_, err := r.do(ctx, "SADD", key, value)
+	return redisError("SetAdd", key, err)
 }
 
 //GetSetStringMembers returns the string members of a set.
 func (r *Redis) GetSetStringMembers(key string) ([]string, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
-	val, e := redis.Strings(conn.Do("SMEMBERS", key))
-	return val, e
+	return r.GetSetStringMembersContext(context.Background(), key)
+}
+
+//GetSetStringMembersContext returns the string members of a set.
+func (r *Redis) GetSetStringMembersContext(ctx context.Context, key string) ([]string, error) {
+	v, err := redis.Strings(r.do(ctx, "SMEMBERS", key))
+	return v, redisError("GetSetStringMembers", key, err)
 }
 
 //SetRemove removes the value from the set.
 func (r *Redis) SetRemove(key string, value interface{}) error {
-	conn := r.redis.Get()
-	defer conn.Close()
-	_, e := conn.Do("SREM", key, value)
-	return e
+	return r.SetRemoveContext(context.Background(), key, value)
+}
+
+//SetRemoveContext removes the value from the set.
+func (r *Redis) SetRemoveContext(ctx context.Context, key string, value interface{}) error {
+	_, err := r.do(ctx, "SREM", key, value)
+	return redisError("SetRemove", key, err)
 }
 
 //SetIsMember returns true if the value is a member of the set.
 func (r *Redis) SetIsMember(key string, value interface{}) (bool, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
-	return redis.Bool(conn.Do("SISMEMBER", key, value))
+	return r.SetIsMemberContext(context.Background(), key, value)
+}
+
+//SetIsMemberContext returns true if the value is a member of the set.
+func (r *Redis) SetIsMemberContext(ctx context.Context, key string, value interface{}) (bool, error) {
+	v, err := redis.Bool(r.do(ctx, "SISMEMBER", key, value))
+	return v, redisError("SetIsMember", key, err)
 }
 
 //PushItemToList pushes an item to the list. Use inFront to specifiy if the item should go in front of at the end of the list.
 func (r *Redis) PushItemToList(key string, value interface{}, atEnd bool) error {
-	conn := r.redis.Get()
-	defer conn.Close()
+	return r.PushItemToListContext(context.Background(), key, value, atEnd)
+}
+
+//PushItemToListContext pushes an item to the front or the end of the list.
+func (r *Redis) PushItemToListContext(ctx context.Context, key string, value interface{}, atEnd bool) error {
 	cmd := "LPUSH"
 	if atEnd {
 		cmd = "RPUSH"
 	}
 
-	_, err := conn.Do(cmd, key, value)
-	return err
+	_, err := r.do(ctx, cmd, key, value)
+	return redisError("PushItemToList", key, err)
 }
 
 //PopItemFromList pops an item from the front or the back of the list.
 func (r *Redis) PopItemFromList(key string, dataType int, atEnd bool) (interface{}, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
+	return r.PopItemFromListContext(context.Background(), key, dataType, atEnd)
+}
+
+//PopItemFromListContext pops an item from the front or the back of the list.
+func (r *Redis) PopItemFromListContext(ctx context.Context, key string, dataType int, atEnd bool) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("PopItemFromList", key, ErrInvalidDataType)
+	}
 
 	cmd := "LPOP"
 	if atEnd {
 		cmd = "RPOP"
 	}
 
-	val, err := conn.Do(cmd, key)
-
-	switch dataType {
-	case DataTypeString:
-		return redis.String(val, err)
-	case DataTypeBool:
-		return redis.Bool(val, err)
-	case DataTypeInt:
-		return redis.Int(val, err)
-	case DataTypeInt64:
-		return redis.Int64(val, err)
-	default:
-		return nil, errors.New("Invalid data type")
+	val, err := redis.String(r.do(ctx, cmd, key))
+	if err != nil {
+		return nil, redisError("PopItemFromList", key, err)
 	}
+
+	item, err := convertItem(val, dataType)
+	return item, opError("PopItemFromList", key, err)
 }
 
-//ItemsFromList returns a list of items from the list from the start to end.
+//ItemsFromList returns a list of items from the list from the start to end. The items are returned as a slice of the
+//data type, such as []int for DataTypeInt.
 func (r *Redis) ItemsFromList(key string, dataType int, start, end int) (interface{}, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
+	return r.ItemsFromListContext(context.Background(), key, dataType, start, end)
+}
 
-	val, err := conn.Do("LRANGE", key, start, end)
+//ItemsFromListContext returns a list of items from the list from the start to end.
+func (r *Redis) ItemsFromListContext(ctx context.Context, key string, dataType int, start, end int) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("ItemsFromList", key, ErrInvalidDataType)
+	}
 
-	switch dataType {
-	case DataTypeString:
-		return redis.Strings(val, err)
-	case DataTypeInt:
-		return redis.Ints(val, err)
-	default:
-		return nil, errors.New("Invalid data type")
+	vals, err := redis.Strings(r.do(ctx, "LRANGE", key, start, end))
+	if err != nil {
+		return nil, redisError("ItemsFromList", key, err)
 	}
+	items, err := convertItems(vals, dataType)
+	return items, opError("ItemsFromList", key, err)
 }
 
 //RemoveItemFromList removes the item from the list with the count occurances.
 func (r *Redis) RemoveItemFromList(key string, count int, value interface{}) error {
-	conn := r.redis.Get()
-	defer conn.Close()
+	return r.RemoveItemFromListContext(context.Background(), key, count, value)
+}
 
-	_, err := conn.Do("LREM", key, count, value)
-	return err
+//RemoveItemFromListContext removes the item from the list with the count occurances.
+func (r *Redis) RemoveItemFromListContext(ctx context.Context, key string, count int, value interface{}) error {
+	_, err := r.do(ctx, "LREM", key, count, value)
+	return redisError("RemoveItemFromList", key, err)
 }
 
 //LengthOfList returns the lenght of the list.
 func (r *Redis) LengthOfList(key string) (int, error) {
-	conn := r.redis.Get()
-	defer conn.Close()
+	return r.LengthOfListContext(context.Background(), key)
+}
+
+//LengthOfListContext returns the lenght of the list.
+func (r *Redis) LengthOfListContext(ctx context.Context, key string) (int, error) {
+	v, err := redis.Int(r.do(ctx, "LLEN", key))
+	return v, redisError("LengthOfList", key, err)
+}
+
+//ClearDataStore clears up all the keys in the redis datastore with FLUSHDB. Stores created in production mode return
+//ErrFlushLocked unless UnlockFlush was called.
+func (r *Redis) ClearDataStore() error {
+	return r.ClearDataStoreContext(context.Background())
+}
+
+//ClearDataStoreContext clears up all the keys in the redis datastore.
+func (r *Redis) ClearDataStoreContext(ctx context.Context) error {
+	if atomic.LoadInt32(&r.flushLocked) == 1 {
+		return opError("ClearDataStore", "", ErrFlushLocked)
+	}
+	_, err := r.do(ctx, "FLUSHDB")
+	return redisError("ClearDataStore", "", err)
+}
+
+//UnlockFlush allows ClearDataStore on a store created in production mode.
+func (r *Redis) UnlockFlush() {
+	atomic.StoreInt32(&r.flushLocked, 0)
+}
+
+//LockFlush makes ClearDataStore return ErrFlushLocked until UnlockFlush is called.
+func (r *Redis) LockFlush() {
+	atomic.StoreInt32(&r.flushLocked, 1)
+}
+
+//DeleteByPattern deletes the keys matching the glob style pattern and returns the number of deleted keys. The keys
+//are found with SCAN and removed with UNLINK in batches, so the server isn't blocked. An empty pattern is refused, use
+//ClearDataStore to delete every key.
+func (r *Redis) DeleteByPattern(pattern string) (int, error) {
+	return r.DeleteByPatternContext(context.Background(), pattern)
+}
 
-	return redis.Int(conn.Do("LLEN", key))
+//DeleteByPatternContext deletes the keys matching the glob style pattern and returns the number of deleted keys.
+func (r *Redis) DeleteByPatternContext(ctx context.Context, pattern string) (int, error) {
+	if pattern == "" {
+		return 0, opError("DeleteByPattern", "", ErrEmptyPattern)
+	}
+
+	n := 0
+	batch := make([]string, 0, deleteBatchSize)
+	unlink := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		deleted, err := redis.Int(r.do(ctx, "UNLINK", redis.Args{}.AddFlat(batch)...))
+		n += deleted
+		batch = batch[:0]
+		return redisError("DeleteByPattern", "", err)
+	}
+
+	it := r.ScanContext(ctx, pattern, deleteBatchSize)
+	for it.Next() {
+		batch = append(batch, it.Val())
+		if len(batch) == deleteBatchSize {
+			if err := unlink(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return n, err
+	}
+	return n, unlink()
 }
 
-//ClearDataStore clears up all the keys in the redis datastore.
-func (r *Redis) ClearDataStore() {
-	conn := r.redis.Get()
-	defer conn.Close()
-	conn.Do("FLUSHDB")
+//stringMap adds the non nil replies to the map under the matching name.
+func stringMap(m map[string]string, names []string, vals []interface{}) error {
+	for i, v := range vals {
+		if v == nil {
+			continue
+		}
+		s, err := redis.String(v, nil)
+		if err != nil {
+			return err
+		}
+		m[names[i]] = s
+	}
+	return nil
 }
@@ -1,16 +1,22 @@
-package store
+package store_test
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/awkhan/go-store/store"
+	"github.com/awkhan/go-store/store/redistest"
 	"github.com/awkhan/go-utility/utility"
 	"github.com/stretchr/testify/assert"
 )
 
-var rs *Redis
+var rs *store.Redis
+
+//srv is the fake server the tests run against, nil when REDIS_HOST is set.
+var srv *redistest.Server
 
 func TestMain(m *testing.M) {
 	maxIdle, _ := strconv.ParseInt(os.Getenv("REDIS_MAX_IDLE"), 10, 0)
@@ -19,9 +25,24 @@ func TestMain(m *testing.M) {
 	port := os.Getenv("REDIS_PORT")
 	password := os.Getenv("REDIS_PASSWORD")
 
-	rs = NewRedisStore(int(maxIdle), int(timeout), host, port, password)
+	//Without a redis host the tests run against an in-process fake server. The fake runs commands on a MemoryStore, so
+	//only a real server checks the behavior of redis itself.
+	if host == "" {
+		var err error
+		srv, err = redistest.NewServer()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to start fake redis server: %v\n", err)
+			os.Exit(1)
+		}
+		host, port, password = srv.Host(), srv.Port(), ""
+	}
+
+	rs = store.NewRedisStore(int(maxIdle), int(timeout), host, port, password)
 
 	exitVal := m.Run()
+	if srv != nil {
+		srv.Close()
+	}
 	os.Exit(exitVal)
 }
 
@@ -55,7 +76,8 @@ func TestRedisGetSetDelString(t *testing.T) {
 	assert.Nil(t, err, "Error fetching string %v", err)
 	assert.Equal(t, v, fv, "Invalid fetched value")
 
-	assert.Nil(t, rs.DeleteKey(k))
+	_, err = rs.DeleteKey(k)
+	assert.Nil(t, err, "Error deleting key %v", err)
 }
 
 func TestRedisGetSetDelInt(t *testing.T) {
@@ -73,7 +95,8 @@ func TestRedisGetSetDelInt(t *testing.T) {
 	assert.Nil(t, err, "Error fetching int64 %v", err)
 	assert.Equal(t, v, fv, "Invalid fetched value")
 
-	assert.Nil(t, rs.DeleteKey(k))
+	_, err = rs.DeleteKey(k)
+	assert.Nil(t, err, "Error deleting key %v", err)
 }
 
 func TestRedisIncrDecr(t *testing.T) {
@@ -165,7 +188,7 @@ func TestRedisListString(t *testing.T) {
 	lastItem := items[len(items)-1]
 	items = items[:len(items)-1]
 
-	item, err := rs.PopItemFromList(k, DataTypeString, true)
+	item, err := rs.PopItemFromList(k, store.DataTypeString, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 
 	switch ty := item.(type) {
@@ -178,7 +201,7 @@ func TestRedisListString(t *testing.T) {
 	firstItem := items[0]
 	items = items[1:len(items)]
 
-	item, err = rs.PopItemFromList(k, DataTypeString, false)
+	item, err = rs.PopItemFromList(k, store.DataTypeString, false)
 	assert.Nil(t, err, "error popping item from list %v", err)
 
 	switch ty := item.(type) {
@@ -200,7 +223,7 @@ func TestRedisListRange(t *testing.T) {
 		assert.Nil(t, err, "Error pushing item to list %v", err)
 	}
 
-	fi, err := rs.ItemsFromList(k, DataTypeString, 0, 2)
+	fi, err := rs.ItemsFromList(k, store.DataTypeString, 0, 2)
 	assert.Nil(t, err, "Error retrieving items from list %v", err)
 
 	switch fetchedItems := fi.(type) {
@@ -229,7 +252,7 @@ func TestRedisListRemoveValue(t *testing.T) {
 	err := rs.RemoveItemFromList(k, 0, "abcdefg")
 	assert.Nil(t, err, "Error removing item from list %v", err)
 
-	fi, err := rs.ItemsFromList(k, DataTypeString, 0, 2)
+	fi, err := rs.ItemsFromList(k, store.DataTypeString, 0, 2)
 	assert.Nil(t, err, "Error retrieving items from list %v", err)
 
 	items = []string{items[2], items[3]}
@@ -255,7 +278,7 @@ func TestRedisListInt(t *testing.T) {
 		assert.Nil(t, err, "Error pushing item to list %v", err)
 	}
 
-	fi, err := rs.ItemsFromList(k, DataTypeInt, 0, 3)
+	fi, err := rs.ItemsFromList(k, store.DataTypeInt, 0, 3)
 	assert.Nil(t, err, "Error retrieving items from list %v", err)
 
 	switch fetchedItems := fi.(type) {
@@ -282,15 +305,15 @@ func TestRedisPopBool(t *testing.T) {
 		assert.Nil(t, err, "Error pushing item to list %v", err)
 	}
 
-	i, err := rs.PopItemFromList(k, DataTypeBool, true)
+	i, err := rs.PopItemFromList(k, store.DataTypeBool, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[2], i, "Last item should be true")
 
-	i, err = rs.PopItemFromList(k, DataTypeBool, true)
+	i, err = rs.PopItemFromList(k, store.DataTypeBool, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[1], i, "Middle item should be false")
 
-	i, err = rs.PopItemFromList(k, DataTypeBool, true)
+	i, err = rs.PopItemFromList(k, store.DataTypeBool, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[0], i, "First item should be true")
 }
@@ -306,15 +329,15 @@ func TestRedisPopInt(t *testing.T) {
 		assert.Nil(t, err, "Error pushing item to list %v", err)
 	}
 
-	i, err := rs.PopItemFromList(k, DataTypeInt, true)
+	i, err := rs.PopItemFromList(k, store.DataTypeInt, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[2], i, "Last item should be 3")
 
-	i, err = rs.PopItemFromList(k, DataTypeInt, true)
+	i, err = rs.PopItemFromList(k, store.DataTypeInt, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[1], i, "Middle item should be 2")
 
-	i, err = rs.PopItemFromList(k, DataTypeInt, true)
+	i, err = rs.PopItemFromList(k, store.DataTypeInt, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[0], i, "First item should be 1")
 }
@@ -330,15 +353,15 @@ func TestRedisPopInt64(t *testing.T) {
 		assert.Nil(t, err, "Error pushing item to list %v", err)
 	}
 
-	i, err := rs.PopItemFromList(k, DataTypeInt64, true)
+	i, err := rs.PopItemFromList(k, store.DataTypeInt64, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[2], i, "Last item should be 3")
 
-	i, err = rs.PopItemFromList(k, DataTypeInt64, true)
+	i, err = rs.PopItemFromList(k, store.DataTypeInt64, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[1], i, "Middle item should be 2")
 
-	i, err = rs.PopItemFromList(k, DataTypeInt64, true)
+	i, err = rs.PopItemFromList(k, store.DataTypeInt64, true)
 	assert.Nil(t, err, "Error popping item from list %v", err)
 	assert.Equal(t, items[0], i, "First item should be 1")
 }
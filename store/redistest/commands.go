@@ -0,0 +1,1250 @@
+package redistest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/awkhan/go-store/store"
+)
+
+//command is a redis command supported by the server. A positive arity is the exact number of arguments including the
+//command name, a negative arity is the minimum number of arguments. Write commands invalidate transactions watching
+//the keys they change.
+type command struct {
+	arity int
+	fn    func(s *Server, c *client, args []string) interface{}
+	write bool
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"auth":             {-2, cmdAuth, false},
+		"select":           {2, cmdSelect, false},
+		"ping":             {-1, cmdPing, false},
+		"echo":             {2, cmdEcho, false},
+		"flushdb":          {1, cmdFlushDB, false},
+		"flushall":         {1, cmdFlushAll, false},
+		"get":              {2, cmdGet, false},
+		"set":              {-3, cmdSet, true},
+		"getset":           {3, cmdGetSet, true},
+		"mget":             {-2, cmdMGet, false},
+		"mset":             {-3, cmdMSet, true},
+		"del":              {-2, cmdDel, true},
+		"unlink":           {-2, cmdDel, true},
+		"exists":           {-2, cmdExists, false},
+		"touch":            {-2, cmdTouch, false},
+		"type":             {2, cmdType, false},
+		"rename":           {3, cmdRename, true},
+		"renamenx":         {3, cmdRenameNX, true},
+		"copy":             {-3, cmdCopy, true},
+		"expire":           {3, cmdExpire, true},
+		"pexpire":          {3, cmdPExpire, true},
+		"pexpireat":        {3, cmdPExpireAt, true},
+		"persist":          {2, cmdPersist, true},
+		"pttl":             {2, cmdPTTL, false},
+		"incr":             {2, cmdIncr, true},
+		"decr":             {2, cmdDecr, true},
+		"incrby":           {3, cmdIncrBy, true},
+		"decrby":           {3, cmdDecrBy, true},
+		"incrbyfloat":      {3, cmdIncrByFloat, true},
+		"hincrby":          {4, cmdHIncrBy, true},
+		"hset":             {4, cmdHSet, true},
+		"hdel":             {-3, cmdHDel, true},
+		"hget":             {3, cmdHGet, false},
+		"hexists":          {3, cmdHExists, false},
+		"hlen":             {2, cmdHLen, false},
+		"hsetnx":           {4, cmdHSetNX, true},
+		"hvals":            {2, cmdHVals, false},
+		"hkeys":            {2, cmdHKeys, false},
+		"hmset":            {-4, cmdHMSet, true},
+		"hmget":            {-3, cmdHMGet, false},
+		"hgetall":          {2, cmdHGetAll, false},
+		"sadd":             {-3, cmdSAdd, true},
+		"srem":             {-3, cmdSRem, true},
+		"smembers":         {2, cmdSMembers, false},
+		"sismember":        {3, cmdSIsMember, false},
+		"sunion":           {-2, cmdSUnion, false},
+		"sinter":           {-2, cmdSInter, false},
+		"sdiff":            {-2, cmdSDiff, false},
+		"sunionstore":      {-3, cmdSUnionStore, true},
+		"sinterstore":      {-3, cmdSInterStore, true},
+		"sdiffstore":       {-3, cmdSDiffStore, true},
+		"scard":            {2, cmdSCard, false},
+		"spop":             {-2, cmdSPop, true},
+		"srandmember":      {-2, cmdSRandMember, false},
+		"zadd":             {-4, cmdZAdd, true},
+		"zincrby":          {4, cmdZIncrBy, true},
+		"zscore":           {3, cmdZScore, false},
+		"zrank":            {3, cmdZRank, false},
+		"zrevrank":         {3, cmdZRevRank, false},
+		"zrangebyscore":    {-4, cmdZRangeByScore, false},
+		"zrangebylex":      {-4, cmdZRangeByLex, false},
+		"zrem":             {-3, cmdZRem, true},
+		"zremrangebyscore": {4, cmdZRemRangeByScore, true},
+		"zcard":            {2, cmdZCard, false},
+		"lpush":            {-3, cmdLPush, true},
+		"rpush":            {-3, cmdRPush, true},
+		"lpop":             {2, cmdLPop, true},
+		"rpop":             {2, cmdRPop, true},
+		"lrange":           {4, cmdLRange, false},
+		"lrem":             {4, cmdLRem, true},
+		"llen":             {2, cmdLLen, false},
+		"lindex":           {3, cmdLIndex, false},
+		"lset":             {4, cmdLSet, true},
+		"ltrim":            {4, cmdLTrim, true},
+		"linsert":          {5, cmdLInsert, true},
+		"rpoplpush":        {3, cmdRPopLPush, true},
+		"blpop":            {-3, cmdBLPop, true},
+		"brpop":            {-3, cmdBRPop, true},
+		"brpoplpush":       {4, cmdBRPopLPush, true},
+		"multi":            {1, cmdMulti, false},
+		"exec":             {1, cmdExec, false},
+		"discard":          {1, cmdDiscard, false},
+		"watch":            {-2, cmdWatch, false},
+		"unwatch":          {1, cmdUnwatch, false},
+		"eval":             {-3, cmdEval, true},
+		"evalsha":          {-3, cmdEvalSHA, true},
+		"script":           {-2, cmdScript, false},
+		"scan":             {-2, cmdScan, false},
+		"hscan":            {-3, cmdHScan, false},
+		"sscan":            {-3, cmdSScan, false},
+	}
+}
+
+var (
+	errNotInteger = errorReply("ERR value is not an integer or out of range")
+	errNotFloat   = errorReply("ERR value is not a valid float")
+)
+
+//db returns the store for the database selected by the client.
+func (s *Server) db(c *client) *store.MemoryStore {
+	return s.dbs[c.db]
+}
+
+//storeError converts an error returned by the memory store to a reply. Missing values become nil replies.
+func storeError(err error) interface{} {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil
+	case errors.Is(err, store.ErrWrongType):
+		return errorReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+	case errors.Is(err, store.ErrNotInteger):
+		return errNotInteger
+	case errors.Is(err, store.ErrNotFloat):
+		return errNotFloat
+	case errors.Is(err, store.ErrIndexOutOfRange):
+		return errorReply("ERR index out of range")
+	}
+	var opErr *store.OpError
+	if errors.As(err, &opErr) {
+		err = opErr.Err
+	}
+	return errorReply("ERR " + err.Error())
+}
+
+//isNotFound returns true if the error reports a missing key, field or item.
+func isNotFound(err error) bool {
+	return errors.Is(err, store.ErrNotFound)
+}
+
+//exists returns true if the key holds a value of any type.
+func exists(db *store.MemoryStore, key string) bool {
+	n, _ := db.Exists(key)
+	return n > 0
+}
+
+func cmdAuth(s *Server, c *client, args []string) interface{} {
+	if s.Password == "" {
+		return errorReply("ERR Client sent AUTH, but no password is set")
+	}
+	if len(args) > 2 {
+		return errorReply("ERR syntax error")
+	}
+	if len(args) == 2 {
+		if args[0] != "default" || args[1] != s.Password {
+			c.authed = false
+			return errorReply("WRONGPASS invalid username-password pair")
+		}
+	} else if args[0] != s.Password {
+		c.authed = false
+		return errorReply("ERR invalid password")
+	}
+	c.authed = true
+	return statusReply("OK")
+}
+
+func cmdSelect(s *Server, c *client, args []string) interface{} {
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errNotInteger
+	}
+	if index < 0 || index >= len(s.dbs) {
+		return errorReply("ERR DB index is out of range")
+	}
+	c.db = index
+	return statusReply("OK")
+}
+
+func cmdPing(s *Server, c *client, args []string) interface{} {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return statusReply("PONG")
+}
+
+func cmdEcho(s *Server, c *client, args []string) interface{} {
+	return args[0]
+}
+
+func cmdFlushDB(s *Server, c *client, args []string) interface{} {
+	s.db(c).ClearDataStore()
+	s.flush(c.db)
+	return statusReply("OK")
+}
+
+func cmdFlushAll(s *Server, c *client, args []string) interface{} {
+	for i, db := range s.dbs {
+		db.ClearDataStore()
+		s.flush(i)
+	}
+	return statusReply("OK")
+}
+
+func cmdGet(s *Server, c *client, args []string) interface{} {
+	v, err := s.db(c).GetString(args[0])
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdSet(s *Server, c *client, args []string) interface{} {
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 2; i < len(args); i++ {
+		opt := strings.ToLower(args[i])
+		switch {
+		case opt == "nx":
+			nx = true
+			continue
+		case opt == "xx":
+			xx = true
+			continue
+		case opt != "ex" && opt != "px" || i+1 == len(args):
+			return errorReply("ERR syntax error")
+		}
+		n, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil {
+			return errNotInteger
+		}
+		if n <= 0 {
+			return errorReply("ERR invalid expire time in 'set' command")
+		}
+		ttl = time.Duration(n) * time.Millisecond
+		if opt == "ex" {
+			ttl = time.Duration(n) * time.Second
+		}
+		i++
+	}
+
+	if nx && xx {
+		return errorReply("ERR syntax error")
+	}
+	db := s.db(c)
+	if nx && exists(db, args[0]) || xx && !exists(db, args[0]) {
+		return nil
+	}
+	var err error
+	if ttl > 0 {
+		err = db.SetWithTTL(args[0], args[1], ttl)
+	} else {
+		err = db.Set(args[0], args[1])
+	}
+	if err != nil {
+		return storeError(err)
+	}
+	return statusReply("OK")
+}
+
+//mapReply returns the values for the names in order, with nil replies for the missing names.
+func mapReply(vals map[string]string, names []string) []interface{} {
+	reply := make([]interface{}, len(names))
+	for i, n := range names {
+		if v, ok := vals[n]; ok {
+			reply[i] = v
+		}
+	}
+	return reply
+}
+
+func cmdMGet(s *Server, c *client, args []string) interface{} {
+	vals, err := s.db(c).MGetStrings(args...)
+	if err != nil {
+		return storeError(err)
+	}
+	return mapReply(vals, args)
+}
+
+func cmdMSet(s *Server, c *client, args []string) interface{} {
+	if len(args)%2 != 0 {
+		return errorReply("ERR wrong number of arguments for 'mset' command")
+	}
+	values := make(map[string]interface{}, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		values[args[i]] = args[i+1]
+	}
+	if err := s.db(c).MSet(values); err != nil {
+		return storeError(err)
+	}
+	return statusReply("OK")
+}
+
+func cmdDel(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).DeleteKey(args...))
+}
+
+func cmdExists(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).Exists(args...))
+}
+
+func cmdTouch(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).Touch(args...))
+}
+
+func cmdType(s *Server, c *client, args []string) interface{} {
+	t, err := s.db(c).Type(args[0])
+	if err != nil {
+		return storeError(err)
+	}
+	return statusReply(t.String())
+}
+
+func cmdRename(s *Server, c *client, args []string) interface{} {
+	err := s.db(c).Rename(args[0], args[1])
+	if isNotFound(err) {
+		return errorReply("ERR no such key")
+	}
+	if err != nil {
+		return storeError(err)
+	}
+	return statusReply("OK")
+}
+
+func cmdRenameNX(s *Server, c *client, args []string) interface{} {
+	ok, err := s.db(c).RenameIfNotExists(args[0], args[1])
+	if isNotFound(err) {
+		return errorReply("ERR no such key")
+	}
+	return boolReply(ok, err)
+}
+
+func cmdCopy(s *Server, c *client, args []string) interface{} {
+	replace := false
+	for _, opt := range args[2:] {
+		if strings.ToUpper(opt) != "REPLACE" {
+			return errorReply("ERR syntax error")
+		}
+		replace = true
+	}
+	return boolReply(s.db(c).Copy(args[0], args[1], replace))
+}
+
+func cmdExpire(s *Server, c *client, args []string) interface{} {
+	seconds, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errNotInteger
+	}
+	db := s.db(c)
+	if !exists(db, args[0]) {
+		return 0
+	}
+	if err := db.SetExpiry(args[0], seconds); err != nil {
+		return storeError(err)
+	}
+	return 1
+}
+
+func cmdGetSet(s *Server, c *client, args []string) interface{} {
+	v, ok, err := s.db(c).GetAndSet(args[0], args[1])
+	if err != nil {
+		return storeError(err)
+	}
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func cmdPExpire(s *Server, c *client, args []string) interface{} {
+	ms, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	db := s.db(c)
+	if !exists(db, args[0]) {
+		return 0
+	}
+	if err := db.Expire(args[0], time.Duration(ms)*time.Millisecond); err != nil {
+		return storeError(err)
+	}
+	return 1
+}
+
+func cmdPExpireAt(s *Server, c *client, args []string) interface{} {
+	ms, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	db := s.db(c)
+	if !exists(db, args[0]) {
+		return 0
+	}
+	if err := db.ExpireAt(args[0], time.Unix(0, ms*int64(time.Millisecond))); err != nil {
+		return storeError(err)
+	}
+	return 1
+}
+
+func cmdPersist(s *Server, c *client, args []string) interface{} {
+	db := s.db(c)
+	ttl, err := db.TTL(args[0])
+	if err != nil || ttl == store.NoExpiry {
+		return 0
+	}
+	if err := db.Persist(args[0]); err != nil {
+		return storeError(err)
+	}
+	return 1
+}
+
+func cmdPTTL(s *Server, c *client, args []string) interface{} {
+	ttl, err := s.db(c).TTL(args[0])
+	if isNotFound(err) {
+		return int64(-2)
+	}
+	if err != nil {
+		return storeError(err)
+	}
+	if ttl == store.NoExpiry {
+		return int64(-1)
+	}
+	return int64(ttl / time.Millisecond)
+}
+
+//intReply returns the integer result of a counter operation.
+func intReply(v int64, err error) interface{} {
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdIncr(s *Server, c *client, args []string) interface{} {
+	return intReply(s.db(c).IncrementBy(args[0], 1))
+}
+
+func cmdDecr(s *Server, c *client, args []string) interface{} {
+	return intReply(s.db(c).DecrementBy(args[0], 1))
+}
+
+func cmdIncrBy(s *Server, c *client, args []string) interface{} {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	return intReply(s.db(c).IncrementBy(args[0], n))
+}
+
+func cmdDecrBy(s *Server, c *client, args []string) interface{} {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	return intReply(s.db(c).DecrementBy(args[0], n))
+}
+
+func cmdIncrByFloat(s *Server, c *client, args []string) interface{} {
+	n, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return errNotFloat
+	}
+	v, err := s.db(c).IncrementByFloat(args[0], n)
+	if err != nil {
+		return storeError(err)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func cmdHIncrBy(s *Server, c *client, args []string) interface{} {
+	n, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	return intReply(s.db(c).HashIncrementBy(args[0], args[1], n))
+}
+
+func cmdHSet(s *Server, c *client, args []string) interface{} {
+	db := s.db(c)
+	_, err := db.GetHashString(args[0], args[1])
+	isNew := isNotFound(err)
+	if err != nil && !isNew {
+		return storeError(err)
+	}
+	if err := db.SetHash(args[0], args[1], args[2]); err != nil {
+		return storeError(err)
+	}
+	if isNew {
+		return 1
+	}
+	return 0
+}
+
+func cmdHDel(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).HashDelete(args[0], args[1:]...))
+}
+
+func cmdHExists(s *Server, c *client, args []string) interface{} {
+	return boolReply(s.db(c).HashExists(args[0], args[1]))
+}
+
+func cmdHLen(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).HashLength(args[0]))
+}
+
+func cmdHSetNX(s *Server, c *client, args []string) interface{} {
+	return boolReply(s.db(c).HashSetIfNotExists(args[0], args[1], args[2]))
+}
+
+func cmdHGet(s *Server, c *client, args []string) interface{} {
+	v, err := s.db(c).GetHashString(args[0], args[1])
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdHVals(s *Server, c *client, args []string) interface{} {
+	v, err := s.db(c).GetAllHashValues(args[0])
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdHKeys(s *Server, c *client, args []string) interface{} {
+	v, err := s.db(c).GetAllHashKeys(args[0])
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdHMSet(s *Server, c *client, args []string) interface{} {
+	if len(args)%2 != 1 {
+		return errorReply("ERR wrong number of arguments for 'hmset' command")
+	}
+	values := make(map[string]interface{}, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		values[args[i]] = args[i+1]
+	}
+	if err := s.db(c).HMSet(args[0], values); err != nil {
+		return storeError(err)
+	}
+	return statusReply("OK")
+}
+
+func cmdHMGet(s *Server, c *client, args []string) interface{} {
+	vals, err := s.db(c).HMGet(args[0], args[1:]...)
+	if err != nil {
+		return storeError(err)
+	}
+	return mapReply(vals, args[1:])
+}
+
+func cmdHGetAll(s *Server, c *client, args []string) interface{} {
+	vals, err := s.db(c).HGetAll(args[0])
+	if err != nil {
+		return storeError(err)
+	}
+	reply := make([]string, 0, 2*len(vals))
+	for k, v := range vals {
+		reply = append(reply, k, v)
+	}
+	return reply
+}
+
+func cmdSAdd(s *Server, c *client, args []string) interface{} {
+	db := s.db(c)
+	n := 0
+	for _, m := range args[1:] {
+		ok, err := db.SetIsMember(args[0], m)
+		if err != nil {
+			return storeError(err)
+		}
+		if err := db.SetAdd(args[0], m); err != nil {
+			return storeError(err)
+		}
+		if !ok {
+			n++
+		}
+	}
+	return n
+}
+
+func cmdSRem(s *Server, c *client, args []string) interface{} {
+	db := s.db(c)
+	n := 0
+	for _, m := range args[1:] {
+		ok, err := db.SetIsMember(args[0], m)
+		if err != nil {
+			return storeError(err)
+		}
+		if !ok {
+			continue
+		}
+		if err := db.SetRemove(args[0], m); err != nil {
+			return storeError(err)
+		}
+		n++
+	}
+	return n
+}
+
+func cmdSMembers(s *Server, c *client, args []string) interface{} {
+	v, err := s.db(c).GetSetStringMembers(args[0])
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdSIsMember(s *Server, c *client, args []string) interface{} {
+	return boolReply(s.db(c).SetIsMember(args[0], args[1]))
+}
+
+//resultReply converts a result returned by the memory store to a reply.
+func resultReply(v interface{}, err error) interface{} {
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+//boolReply converts a bool returned by the memory store to an integer reply.
+func boolReply(ok bool, err error) interface{} {
+	if err != nil {
+		return storeError(err)
+	}
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+func cmdSUnion(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).SetUnion(args...))
+}
+
+func cmdSInter(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).SetIntersect(args...))
+}
+
+func cmdSDiff(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).SetDiff(args...))
+}
+
+func cmdSUnionStore(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).SetUnionStore(args[0], args[1:]...))
+}
+
+func cmdSInterStore(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).SetIntersectStore(args[0], args[1:]...))
+}
+
+func cmdSDiffStore(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).SetDiffStore(args[0], args[1:]...))
+}
+
+func cmdSCard(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).SetCardinality(args[0]))
+}
+
+func cmdSPop(s *Server, c *client, args []string) interface{} {
+	if len(args) > 2 {
+		return errorReply("ERR syntax error")
+	}
+	if len(args) == 1 {
+		v, err := s.db(c).SetPop(args[0], 1)
+		if err != nil || len(v) == 0 {
+			return storeError(err)
+		}
+		return v[0]
+	}
+	count, err := strconv.Atoi(args[1])
+	if err != nil || count < 0 {
+		return errorReply("ERR value is out of range, must be positive")
+	}
+	return resultReply(s.db(c).SetPop(args[0], count))
+}
+
+func cmdSRandMember(s *Server, c *client, args []string) interface{} {
+	if len(args) > 2 {
+		return errorReply("ERR syntax error")
+	}
+	if len(args) == 1 {
+		v, err := s.db(c).SetRandomMembers(args[0], 1)
+		if err != nil || len(v) == 0 {
+			return storeError(err)
+		}
+		return v[0]
+	}
+	count, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errNotInteger
+	}
+	return resultReply(s.db(c).SetRandomMembers(args[0], count))
+}
+
+//push pushes the values to the list and returns its new length.
+func push(db *store.MemoryStore, key string, values []string, atEnd bool) interface{} {
+	for _, v := range values {
+		if err := db.PushItemToList(key, v, atEnd); err != nil {
+			return storeError(err)
+		}
+	}
+	return listLength(db, key)
+}
+
+func cmdLPush(s *Server, c *client, args []string) interface{} {
+	return push(s.db(c), args[0], args[1:], false)
+}
+
+func cmdRPush(s *Server, c *client, args []string) interface{} {
+	return push(s.db(c), args[0], args[1:], true)
+}
+
+//pop pops a value from either end of the list.
+func pop(db *store.MemoryStore, key string, atEnd bool) interface{} {
+	v, err := db.PopItemFromList(key, store.DataTypeString, atEnd)
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdLPop(s *Server, c *client, args []string) interface{} {
+	return pop(s.db(c), args[0], false)
+}
+
+func cmdRPop(s *Server, c *client, args []string) interface{} {
+	return pop(s.db(c), args[0], true)
+}
+
+func cmdLRange(s *Server, c *client, args []string) interface{} {
+	start, err1 := strconv.Atoi(args[1])
+	end, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil {
+		return errNotInteger
+	}
+	v, err := s.db(c).ItemsFromList(args[0], store.DataTypeString, start, end)
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdLRem(s *Server, c *client, args []string) interface{} {
+	count, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errNotInteger
+	}
+	db := s.db(c)
+	before, err := db.LengthOfList(args[0])
+	if err != nil {
+		return storeError(err)
+	}
+	if err := db.RemoveItemFromList(args[0], count, args[2]); err != nil {
+		return storeError(err)
+	}
+	after, _ := db.LengthOfList(args[0])
+	return before - after
+}
+
+//listLength returns the length of the list as a reply.
+func listLength(db *store.MemoryStore, key string) interface{} {
+	n, err := db.LengthOfList(key)
+	if err != nil {
+		return storeError(err)
+	}
+	return n
+}
+
+func cmdLLen(s *Server, c *client, args []string) interface{} {
+	return listLength(s.db(c), args[0])
+}
+
+func cmdLIndex(s *Server, c *client, args []string) interface{} {
+	index, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errNotInteger
+	}
+	v, err := s.db(c).ItemFromList(args[0], store.DataTypeString, index)
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdLSet(s *Server, c *client, args []string) interface{} {
+	index, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errNotInteger
+	}
+	err = s.db(c).SetItemInList(args[0], index, args[2])
+	if isNotFound(err) {
+		return errorReply("ERR no such key")
+	}
+	if err != nil {
+		return storeError(err)
+	}
+	return statusReply("OK")
+}
+
+func cmdLTrim(s *Server, c *client, args []string) interface{} {
+	start, err1 := strconv.Atoi(args[1])
+	end, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil {
+		return errNotInteger
+	}
+	if err := s.db(c).TrimList(args[0], start, end); err != nil {
+		return storeError(err)
+	}
+	return statusReply("OK")
+}
+
+func cmdLInsert(s *Server, c *client, args []string) interface{} {
+	var before bool
+	switch strings.ToUpper(args[1]) {
+	case "BEFORE":
+		before = true
+	case "AFTER":
+	default:
+		return errorReply("ERR syntax error")
+	}
+	db := s.db(c)
+	n, err := db.InsertItemInList(args[0], args[2], args[3], before)
+	if isNotFound(err) {
+		//Redis replies 0 for a missing list and -1 for a missing pivot.
+		if exists(db, args[0]) {
+			return -1
+		}
+		return 0
+	}
+	if err != nil {
+		return storeError(err)
+	}
+	return n
+}
+
+func cmdRPopLPush(s *Server, c *client, args []string) interface{} {
+	v, err := s.db(c).MoveItemToList(args[0], args[1], store.DataTypeString)
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+//formatScore formats a sorted set score the way redis replies with it.
+func formatScore(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(v, 'g', 17, 64)
+}
+
+func cmdZAdd(s *Server, c *client, args []string) interface{} {
+	var opts store.ZAddOptions
+	i := 1
+flags:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			opts.NX = true
+		case "XX":
+			opts.XX = true
+		case "GT":
+			opts.GT = true
+		case "LT":
+			opts.LT = true
+		default:
+			break flags
+		}
+	}
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return errorReply("ERR syntax error")
+	}
+	members := make([]store.ZMember, len(pairs)/2)
+	for j := range members {
+		score, err := strconv.ParseFloat(pairs[2*j], 64)
+		if err != nil || math.IsNaN(score) {
+			return errNotFloat
+		}
+		members[j] = store.ZMember{Member: pairs[2*j+1], Score: score}
+	}
+	return resultReply(s.db(c).ZAdd(args[0], opts, members...))
+}
+
+func cmdZIncrBy(s *Server, c *client, args []string) interface{} {
+	n, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || math.IsNaN(n) {
+		return errNotFloat
+	}
+	v, err := s.db(c).ZIncrBy(args[0], args[2], n)
+	if err != nil {
+		return storeError(err)
+	}
+	return formatScore(v)
+}
+
+func cmdZScore(s *Server, c *client, args []string) interface{} {
+	v, err := s.db(c).ZScore(args[0], args[1])
+	if err != nil {
+		return storeError(err)
+	}
+	return formatScore(v)
+}
+
+func cmdZRank(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).ZRank(args[0], args[1]))
+}
+
+func cmdZRevRank(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).ZRevRank(args[0], args[1]))
+}
+
+//parseRangeBy parses the bounds of a range command followed by the WITHSCORES and LIMIT options.
+func parseRangeBy(args []string, withScores bool) (store.ZRangeBy, bool, interface{}) {
+	by := store.ZRangeBy{Min: args[1], Max: args[2]}
+	scores := false
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "WITHSCORES" && withScores:
+			scores = true
+		case opt == "LIMIT" && i+2 < len(args):
+			offset, err1 := strconv.Atoi(args[i+1])
+			count, err2 := strconv.Atoi(args[i+2])
+			if err1 != nil || err2 != nil {
+				return by, false, errNotInteger
+			}
+			if count == 0 {
+				//A zero count returns no members while ZRangeBy treats it as no limit.
+				count, offset = 1, -1
+			}
+			by.Offset, by.Count = offset, count
+			i += 2
+		default:
+			return by, false, errorReply("ERR syntax error")
+		}
+	}
+	return by, scores, nil
+}
+
+func cmdZRangeByScore(s *Server, c *client, args []string) interface{} {
+	by, withScores, errReply := parseRangeBy(args, true)
+	if errReply != nil {
+		return errReply
+	}
+	members, err := s.db(c).ZRangeByScore(args[0], by)
+	if err != nil {
+		return storeError(err)
+	}
+	reply := make([]string, 0, 2*len(members))
+	for _, m := range members {
+		reply = append(reply, m.Member)
+		if withScores {
+			reply = append(reply, formatScore(m.Score))
+		}
+	}
+	return reply
+}
+
+func cmdZRangeByLex(s *Server, c *client, args []string) interface{} {
+	by, _, errReply := parseRangeBy(args, false)
+	if errReply != nil {
+		return errReply
+	}
+	return resultReply(s.db(c).ZRangeByLex(args[0], by))
+}
+
+func cmdZRem(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).ZRem(args[0], args[1:]...))
+}
+
+func cmdZRemRangeByScore(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).ZRemRangeByScore(args[0], args[1], args[2]))
+}
+
+func cmdZCard(s *Server, c *client, args []string) interface{} {
+	return resultReply(s.db(c).ZCard(args[0]))
+}
+
+func cmdMulti(s *Server, c *client, args []string) interface{} {
+	if c.multi {
+		return errorReply("ERR MULTI calls can not be nested")
+	}
+	c.multi = true
+	return statusReply("OK")
+}
+
+func cmdExec(s *Server, c *client, args []string) interface{} {
+	if !c.multi {
+		return errorReply("ERR EXEC without MULTI")
+	}
+	queued, failed, changed := c.queued, c.multiFailed, s.watchChanged(c)
+	c.multi, c.multiFailed, c.queued = false, false, nil
+	s.unwatch(c)
+
+	if failed {
+		return errorReply("EXECABORT Transaction discarded because of previous errors.")
+	}
+	if changed {
+		return nilArray{}
+	}
+
+	replies := make([]interface{}, len(queued))
+	c.inExec = true
+	for i, args := range queued {
+		replies[i] = s.run(c, args)
+	}
+	c.inExec = false
+	return replies
+}
+
+func cmdDiscard(s *Server, c *client, args []string) interface{} {
+	if !c.multi {
+		return errorReply("ERR DISCARD without MULTI")
+	}
+	c.multi, c.multiFailed, c.queued = false, false, nil
+	s.unwatch(c)
+	return statusReply("OK")
+}
+
+func cmdWatch(s *Server, c *client, args []string) interface{} {
+	if c.multi {
+		return errorReply("ERR WATCH inside MULTI is not allowed")
+	}
+	for _, k := range args {
+		s.watch(c, k)
+	}
+	return statusReply("OK")
+}
+
+func cmdUnwatch(s *Server, c *client, args []string) interface{} {
+	s.unwatch(c)
+	return statusReply("OK")
+}
+
+//compareAndSwapScript is the script store.Redis runs for CompareAndSwap. It must match the source in the store byte
+//for byte, as scripts are looked up by their hash.
+const compareAndSwapScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`
+
+//storeScripts holds the implementations of the scripts store.Redis runs itself by their source.
+var storeScripts = map[string]ScriptFunc{
+	compareAndSwapScript: scriptCompareAndSwap,
+}
+
+func scriptCompareAndSwap(db *store.MemoryStore, keys, args []string) (interface{}, error) {
+	swapped, err := db.CompareAndSwap(keys[0], args[0], args[1])
+	if err != nil || !swapped {
+		return int64(0), err
+	}
+	return int64(1), nil
+}
+
+//scriptKeys splits the numkeys argument of EVAL and the arguments following it into keys and arguments.
+func scriptKeys(args []string) ([]string, []string, interface{}) {
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, nil, errorReply("ERR value is not an integer or out of range")
+	}
+	if n < 0 {
+		return nil, nil, errorReply("ERR Number of keys can't be negative")
+	}
+	if n > len(args)-1 {
+		return nil, nil, errorReply("ERR Number of keys can't be greater than number of args")
+	}
+	return args[1 : 1+n], args[1+n:], nil
+}
+
+//runScript runs the registered script with the hash, caching it so EVALSHA finds it.
+func runScript(s *Server, c *client, hash string, args []string) interface{} {
+	keys, argv, reply := scriptKeys(args)
+	if reply != nil {
+		return reply
+	}
+	fn, ok := s.scripts[hash]
+	if !ok {
+		return errorReply("ERR fake server can't run unregistered script " + hash)
+	}
+	s.loaded[hash] = true
+
+	v, err := fn(s.db(c), keys, argv)
+	if err != nil {
+		return storeError(err)
+	}
+	return v
+}
+
+func cmdEval(s *Server, c *client, args []string) interface{} {
+	return runScript(s, c, scriptHash(args[0]), args[1:])
+}
+
+func cmdEvalSHA(s *Server, c *client, args []string) interface{} {
+	hash := strings.ToLower(args[0])
+	if !s.loaded[hash] {
+		return errorReply("NOSCRIPT No matching script. Please use EVAL.")
+	}
+	return runScript(s, c, hash, args[1:])
+}
+
+func cmdScript(s *Server, c *client, args []string) interface{} {
+	switch strings.ToLower(args[0]) {
+	case "load":
+		if len(args) != 2 {
+			return errorReply("ERR wrong number of arguments for 'script|load' command")
+		}
+		hash := scriptHash(args[1])
+		if _, ok := s.scripts[hash]; !ok {
+			return errorReply("ERR fake server can't load unregistered script " + hash)
+		}
+		s.loaded[hash] = true
+		return hash
+	case "exists":
+		found := make([]interface{}, len(args)-1)
+		for i, hash := range args[1:] {
+			found[i] = 0
+			if s.loaded[strings.ToLower(hash)] {
+				found[i] = 1
+			}
+		}
+		return found
+	case "flush":
+		s.loaded = make(map[string]bool)
+		return statusReply("OK")
+	}
+	return errorReply(fmt.Sprintf("ERR unknown subcommand '%s'", args[0]))
+}
+
+//scanReply returns the page of the sorted items of the iterator following the cursor. Cursors remember the last item
+//returned, so items present during the whole iteration are returned once even when others are added or deleted.
+func scanReply(s *Server, args []string, scan func(pattern string) *store.ScanIterator, pairs bool) interface{} {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return errorReply("ERR invalid cursor")
+	}
+	last, ok := s.cursors[cursor]
+	if !ok && cursor != 0 {
+		return errorReply("ERR invalid cursor")
+	}
+	delete(s.cursors, cursor)
+
+	pattern, count := "", 10
+	for i := 1; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return errorReply("ERR syntax error")
+		}
+		switch strings.ToLower(args[i]) {
+		case "match":
+			pattern = args[i+1]
+		case "count":
+			if count, err = strconv.Atoi(args[i+1]); err != nil || count < 1 {
+				return errorReply("ERR syntax error")
+			}
+		default:
+			return errorReply("ERR syntax error")
+		}
+	}
+
+	items := []string{}
+	n := 0
+	it := scan(pattern)
+	for it.Next() {
+		if cursor != 0 && it.Val() <= last {
+			continue
+		}
+		if n == count {
+			s.nextCursor++
+			s.cursors[s.nextCursor] = items[len(items)-1]
+			if pairs {
+				s.cursors[s.nextCursor] = items[len(items)-2]
+			}
+			return []interface{}{strconv.FormatUint(s.nextCursor, 10), items}
+		}
+		items = append(items, it.Val())
+		if pairs {
+			items = append(items, it.HashValue())
+		}
+		n++
+	}
+	if err := it.Err(); err != nil {
+		return storeError(err)
+	}
+	return []interface{}{"0", items}
+}
+
+func cmdScan(s *Server, c *client, args []string) interface{} {
+	return scanReply(s, args, func(pattern string) *store.ScanIterator {
+		return s.db(c).Scan(pattern, 0)
+	}, false)
+}
+
+func cmdHScan(s *Server, c *client, args []string) interface{} {
+	return scanReply(s, args[1:], func(pattern string) *store.ScanIterator {
+		return s.db(c).HScan(args[0], pattern, 0)
+	}, true)
+}
+
+func cmdSScan(s *Server, c *client, args []string) interface{} {
+	return scanReply(s, args[1:], func(pattern string) *store.ScanIterator {
+		return s.db(c).SScan(args[0], pattern, 0)
+	}, false)
+}
+
+//blockingPop pops from the first non empty list of the keys, waiting for the timeout in the last argument.
+func blockingPop(s *Server, c *client, args []string, atEnd bool) interface{} {
+	keys := args[:len(args)-1]
+	return s.block(c, args[len(args)-1], nilArray{}, func(wait time.Duration) (interface{}, error) {
+		key, item, err := s.db(c).BlockingPop(keys, wait, atEnd)
+		return []string{key, item}, err
+	})
+}
+
+func cmdBLPop(s *Server, c *client, args []string) interface{} {
+	return blockingPop(s, c, args, false)
+}
+
+func cmdBRPop(s *Server, c *client, args []string) interface{} {
+	return blockingPop(s, c, args, true)
+}
+
+func cmdBRPopLPush(s *Server, c *client, args []string) interface{} {
+	return s.block(c, args[2], nil, func(wait time.Duration) (interface{}, error) {
+		return s.db(c).BlockingMove(args[0], args[1], wait)
+	})
+}
@@ -0,0 +1,509 @@
+//Package redistest provides an in-process server speaking the redis protocol for use in tests. The server is backed by
+//store.MemoryStore so tests can exercise the redigo code paths of store.Redis without a redis daemon.
+package redistest
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awkhan/go-store/store"
+)
+
+//numDatabases is the number of databases that can be selected, matching the redis default.
+const numDatabases = 16
+
+//Server is a fake redis server listening on a local port.
+type Server struct {
+	//Password is the password clients need to AUTH with. Set it before calling Start.
+	Password string
+
+	mu       sync.Mutex
+	listener net.Listener
+	dbs      []*store.MemoryStore
+	conns    map[net.Conn]struct{}
+	closed   bool
+	latency  time.Duration
+	wg       sync.WaitGroup
+
+	//seq orders writes so transactions can tell if a watched key changed after WATCH. Versions are only recorded
+	//while a client is watching keys.
+	seq      uint64
+	versions map[watchKey]uint64
+	flushed  []uint64
+	watchers int
+
+	//scripts holds the registered scripts by their SHA1 hash and loaded the hashes in the script cache.
+	scripts map[string]ScriptFunc
+	loaded  map[string]bool
+
+	//cursors holds the last item returned by the open scan cursors.
+	cursors    map[uint64]string
+	nextCursor uint64
+}
+
+//ScriptFunc runs a registered script against the database selected by the client, with the keys and arguments passed
+//to EVAL. The reply can be nil, an int64, a string or a []interface{} of those.
+type ScriptFunc func(db *store.MemoryStore, keys, args []string) (interface{}, error)
+
+//watchKey is a key in a database.
+type watchKey struct {
+	db  int
+	key string
+}
+
+//client is the per connection state of the server.
+type client struct {
+	conn   net.Conn
+	db     int
+	authed bool
+
+	multi       bool
+	multiFailed bool
+	inExec      bool
+	queued      [][]string
+	watched     map[watchKey]uint64
+}
+
+//NewServer creates and starts a new plain text server without a password.
+func NewServer() (*Server, error) {
+	s := NewUnstartedServer()
+	if err := s.Start(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//NewUnstartedServer creates a new server that is not yet listening. Call Start after configuring it.
+func NewUnstartedServer() *Server {
+	dbs := make([]*store.MemoryStore, numDatabases)
+	for i := range dbs {
+		dbs[i] = store.NewMemoryStore()
+	}
+	scripts := make(map[string]ScriptFunc, len(storeScripts))
+	for src, fn := range storeScripts {
+		scripts[scriptHash(src)] = fn
+	}
+	return &Server{
+		dbs:      dbs,
+		conns:    make(map[net.Conn]struct{}),
+		versions: make(map[watchKey]uint64),
+		flushed:  make([]uint64, numDatabases),
+		scripts:  scripts,
+		loaded:   make(map[string]bool),
+		cursors:  make(map[uint64]string),
+	}
+}
+
+//Start starts listening on a random local port.
+func (s *Server) Start() error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	s.serve(l)
+	return nil
+}
+
+//StartTLS starts listening on a random local port, serving TLS with the supplied configuration. Set ClientAuth and
+//ClientCAs on the configuration to require client certificates.
+func (s *Server) StartTLS(config *tls.Config) error {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		return err
+	}
+	s.serve(l)
+	return nil
+}
+
+//serve accepts connections from the listener until the server is closed.
+func (s *Server) serve(l net.Listener) {
+	s.listener = l
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.mu.Unlock()
+
+			s.wg.Add(1)
+			go s.handle(&client{conn: conn, authed: s.Password == ""})
+		}
+	}()
+}
+
+//Addr returns the host:port address the server is listening on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+//Host returns the host the server is listening on.
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr())
+	return host
+}
+
+//Port returns the port the server is listening on.
+func (s *Server) Port() string {
+	_, port, _ := net.SplitHostPort(s.Addr())
+	return port
+}
+
+//DB returns the store backing the database with the given index.
+func (s *Server) DB(index int) *store.MemoryStore {
+	return s.dbs[index]
+}
+
+//SetLatency delays every command by d to simulate a slow server.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+//RegisterScript registers fn as the implementation of the Lua script, since the server can't run Lua. EVAL and
+//SCRIPT LOAD fail for scripts that are not registered. The scripts store.Redis runs itself are registered already.
+func (s *Server) RegisterScript(src string, fn ScriptFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[scriptHash(src)] = fn
+}
+
+//scriptHash returns the SHA1 hash scripts are cached by.
+func scriptHash(src string) string {
+	h := sha1.Sum([]byte(src))
+	return hex.EncodeToString(h[:])
+}
+
+//Close stops the server and closes all client connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	err := s.listener.Close()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+//handle reads commands from the client and writes their replies until the connection is closed.
+func (s *Server) handle(c *client) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c.conn)
+		s.unwatch(c)
+		s.mu.Unlock()
+		c.conn.Close()
+	}()
+
+	r := bufio.NewReader(c.conn)
+	w := bufio.NewWriter(c.conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err != io.EOF {
+				writeReply(w, errorReply("ERR Protocol error: "+err.Error()))
+				w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		writeReply(w, s.exec(c, args))
+
+		//Only flush once all pipelined commands have been read.
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+//exec runs a single command for the client. Commands are serialized so each one is atomic. Within MULTI the commands
+//are queued until EXEC.
+func (s *Server) exec(c *client, args []string) interface{} {
+	name := strings.ToLower(args[0])
+	if !c.authed && name != "auth" {
+		return errorReply("NOAUTH Authentication required.")
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		c.multiFailed = c.multi
+		return errorReply(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+	if cmd.arity > 0 && len(args) != cmd.arity || cmd.arity < 0 && len(args) < -cmd.arity {
+		c.multiFailed = c.multi
+		return errorReply(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+	}
+
+	if c.multi {
+		switch name {
+		case "multi", "exec", "discard", "watch":
+		default:
+			c.queued = append(c.queued, args)
+			return statusReply("QUEUED")
+		}
+	}
+
+	s.mu.Lock()
+	latency := s.latency
+	s.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.run(c, args)
+}
+
+//run runs a validated command, recording the keys changed by write commands. The caller must hold the lock.
+func (s *Server) run(c *client, args []string) interface{} {
+	cmd := commands[strings.ToLower(args[0])]
+	reply := cmd.fn(s, c, args[1:])
+	if _, failed := reply.(errorReply); cmd.write && !failed {
+		for _, k := range writtenKeys(args) {
+			s.touch(c.db, k)
+		}
+	}
+	return reply
+}
+
+//writtenKeys returns the keys changed by a write command.
+func writtenKeys(args []string) []string {
+	switch strings.ToLower(args[0]) {
+	case "del", "unlink":
+		return args[1:]
+	case "mset":
+		keys := make([]string, 0, len(args)/2)
+		for i := 1; i < len(args); i += 2 {
+			keys = append(keys, args[i])
+		}
+		return keys
+	case "blpop", "brpop":
+		return args[1 : len(args)-1]
+	case "brpoplpush", "rpoplpush", "rename", "renamenx":
+		return args[1:3]
+	case "copy":
+		return args[2:3]
+	case "eval", "evalsha":
+		keys, _, _ := scriptKeys(args[2:])
+		return keys
+	}
+	return args[1:2]
+}
+
+//blockSlice is the longest a blocking command waits before checking if the server was closed.
+const blockSlice = 100 * time.Millisecond
+
+//block runs a blocking pop, releasing the lock while it waits so other clients can push items. pop is called with the
+//time to wait and returns ErrNotFound if it expired. The command stops waiting when the server is closed and doesn't
+//wait at all inside a transaction, like redis. The caller must hold the lock.
+func (s *Server) block(c *client, seconds string, timedOut interface{}, pop func(wait time.Duration) (interface{}, error)) interface{} {
+	secs, err := strconv.ParseFloat(seconds, 64)
+	if err != nil {
+		return errorReply("ERR timeout is not a float or out of range")
+	}
+	if secs < 0 {
+		return errorReply("ERR timeout is negative")
+	}
+	timeout := time.Duration(secs * float64(time.Second))
+	result := func(reply interface{}, err error) interface{} {
+		if isNotFound(err) {
+			return timedOut
+		}
+		if err != nil {
+			return storeError(err)
+		}
+		return reply
+	}
+	if c.inExec {
+		return result(pop(time.Nanosecond))
+	}
+
+	s.mu.Unlock()
+	defer s.mu.Lock()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		wait := blockSlice
+		if left := time.Until(deadline); timeout > 0 && left < wait {
+			wait = left
+		}
+		if wait <= 0 {
+			wait = time.Nanosecond
+		}
+		reply, err := pop(wait)
+		if !isNotFound(err) {
+			return result(reply, err)
+		}
+
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+		if closed || timeout > 0 && !time.Now().Before(deadline) {
+			return timedOut
+		}
+	}
+}
+
+//touch records a change to the key. The caller must hold the lock.
+func (s *Server) touch(db int, key string) {
+	if s.watchers == 0 {
+		return
+	}
+	s.seq++
+	s.versions[watchKey{db, key}] = s.seq
+}
+
+//flush records that every key of the database changed. The caller must hold the lock.
+func (s *Server) flush(db int) {
+	s.seq++
+	s.flushed[db] = s.seq
+}
+
+//watch starts watching the key for the client. The caller must hold the lock.
+func (s *Server) watch(c *client, key string) {
+	if c.watched == nil {
+		c.watched = make(map[watchKey]uint64)
+		s.watchers++
+	}
+	c.watched[watchKey{c.db, key}] = s.seq
+}
+
+//watchChanged returns true if any key watched by the client changed. The caller must hold the lock.
+func (s *Server) watchChanged(c *client) bool {
+	for k, seq := range c.watched {
+		if s.versions[k] > seq || s.flushed[k.db] > seq {
+			return true
+		}
+	}
+	return false
+}
+
+//unwatch stops watching all the keys of the client. The caller must hold the lock.
+func (s *Server) unwatch(c *client) {
+	if c.watched == nil {
+		return
+	}
+	c.watched = nil
+	s.watchers--
+	if s.watchers == 0 {
+		s.versions = make(map[watchKey]uint64)
+	}
+}
+
+//statusReply is a simple string reply.
+type statusReply string
+
+//errorReply is an error reply.
+type errorReply string
+
+//nilArray is the reply for a missing multi bulk value.
+type nilArray struct{}
+
+//readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, errors.New("invalid multibulk length")
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errors.New("expected '$'")
+		}
+		l, err := strconv.Atoi(line[1:])
+		if err != nil || l < 0 {
+			return nil, errors.New("invalid bulk length")
+		}
+		buf := make([]byte, l+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:l]))
+	}
+	return args, nil
+}
+
+//readLine reads a single CRLF terminated line.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+//writeReply writes the reply using the redis protocol.
+func writeReply(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case statusReply:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case errorReply:
+		fmt.Fprintf(w, "-%s\r\n", v)
+	case int:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case nil:
+		w.WriteString("$-1\r\n")
+	case nilArray:
+		w.WriteString("*-1\r\n")
+	case []string:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, s := range v {
+			writeReply(w, s)
+		}
+	case []interface{}:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, r := range v {
+			writeReply(w, r)
+		}
+	default:
+		writeReply(w, errorReply(fmt.Sprintf("ERR unsupported reply type %T", v)))
+	}
+}
@@ -0,0 +1,129 @@
+package redistest
+
+import (
+	"testing"
+
+	"github.com/awkhan/go-store/store"
+	"github.com/garyburd/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func dial(t *testing.T, s *Server) redis.Conn {
+	c, err := redis.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatalf("Unable to dial server %v", err)
+	}
+	return c
+}
+
+func TestServerAuth(t *testing.T) {
+	s := NewUnstartedServer()
+	s.Password = "secret"
+	assert.Nil(t, s.Start(), "Error starting server")
+	defer s.Close()
+
+	c := dial(t, s)
+	defer c.Close()
+
+	_, err := c.Do("GET", "key")
+	assert.NotNil(t, err, "Commands should fail before authenticating")
+
+	_, err = c.Do("AUTH", "wrong")
+	assert.NotNil(t, err, "Wrong password should be rejected")
+
+	_, err = c.Do("AUTH", "secret")
+	assert.Nil(t, err, "Error authenticating %v", err)
+
+	_, err = redis.String(c.Do("GET", "key"))
+	assert.Equal(t, redis.ErrNil, err, "Missing key should return a nil reply")
+}
+
+func TestServerSelect(t *testing.T) {
+	s, err := NewServer()
+	assert.Nil(t, err, "Error starting server")
+	defer s.Close()
+
+	c := dial(t, s)
+	defer c.Close()
+
+	_, err = c.Do("SET", "key", "zero")
+	assert.Nil(t, err, "Error setting key %v", err)
+
+	_, err = c.Do("SELECT", 1)
+	assert.Nil(t, err, "Error selecting database %v", err)
+
+	_, err = redis.String(c.Do("GET", "key"))
+	assert.Equal(t, redis.ErrNil, err, "Databases should not share keys")
+
+	v, err := s.DB(0).GetString("key")
+	assert.Nil(t, err, "Error fetching key from backing store %v", err)
+	assert.Equal(t, "zero", v, "Invalid value in backing store")
+
+	_, err = c.Do("SELECT", 99)
+	assert.NotNil(t, err, "Selecting an out of range database should fail")
+}
+
+func TestServerReplies(t *testing.T) {
+	s, err := NewServer()
+	assert.Nil(t, err, "Error starting server")
+	defer s.Close()
+
+	c := dial(t, s)
+	defer c.Close()
+
+	n, err := redis.Int(c.Do("RPUSH", "list", "a", "b", "a"))
+	assert.Nil(t, err, "Error pushing to list %v", err)
+	assert.Equal(t, 3, n, "Push should return the list length")
+
+	n, err = redis.Int(c.Do("LREM", "list", 0, "a"))
+	assert.Nil(t, err, "Error removing from list %v", err)
+	assert.Equal(t, 2, n, "Remove should return the number of removed items")
+
+	n, err = redis.Int(c.Do("DEL", "list", "missing"))
+	assert.Nil(t, err, "Error deleting keys %v", err)
+	assert.Equal(t, 1, n, "Delete should return the number of removed keys")
+
+	_, err = c.Do("SET", "key", "abc")
+	assert.Nil(t, err, "Error setting key %v", err)
+
+	_, err = c.Do("INCR", "key")
+	assert.NotNil(t, err, "Incrementing a non numeric value should fail")
+
+	_, err = c.Do("NOSUCHCOMMAND")
+	assert.NotNil(t, err, "Unknown commands should fail")
+}
+
+func TestServerScripts(t *testing.T) {
+	s, err := NewServer()
+	assert.Nil(t, err, "Error starting server")
+	defer s.Close()
+
+	src := "return redis.call('SET', KEYS[1], ARGV[1])"
+	s.RegisterScript(src, func(db *store.MemoryStore, keys, args []string) (interface{}, error) {
+		return statusReply("OK"), db.Set(keys[0], args[0])
+	})
+	script := redis.NewScript(1, src)
+
+	c := dial(t, s)
+	defer c.Close()
+
+	err = script.SendHash(c, "key", "value")
+	assert.Nil(t, err, "Error sending script %v", err)
+	assert.Nil(t, c.Flush(), "Error flushing script")
+	_, err = c.Receive()
+	assert.NotNil(t, err, "Script should not be cached before it is run")
+
+	_, err = script.Do(c, "key", "value")
+	assert.Nil(t, err, "Error running script %v", err)
+
+	v, err := redis.String(c.Do("GET", "key"))
+	assert.Nil(t, err, "Error fetching key %v", err)
+	assert.Equal(t, "value", v, "Invalid value set by script")
+
+	exists, err := redis.Ints(c.Do("SCRIPT", "EXISTS", scriptHash(src), "missing"))
+	assert.Nil(t, err, "Error checking scripts %v", err)
+	assert.Equal(t, []int{1, 0}, exists, "Script should be cached after running")
+
+	_, err = c.Do("EVAL", "return 1", 0)
+	assert.NotNil(t, err, "Unregistered scripts should fail")
+}
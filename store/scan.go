@@ -0,0 +1,337 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//deleteBatchSize is the number of keys DeleteByPattern scans for and deletes at a time.
+const deleteBatchSize = 100
+
+//ScanIterator iterates over the keys of the store, the members of a set or the hash keys and values of a hash a page
+//at a time. Call Next until it returns false and then check Err. It is safe to stop early, an iterator holds no
+//connection or lock between pages.
+//
+//	it := s.Scan("user:*", 100)
+//	for it.Next() {
+//		fmt.Println(it.Val())
+//	}
+//	err := it.Err()
+type ScanIterator struct {
+	fetch func(cursor string) (string, []string, error)
+	pairs bool
+
+	cursor  string
+	started bool
+	page    []string
+	val     string
+	hashVal string
+	err     error
+}
+
+//Next advances to the next item, fetching the next page when needed. It returns false once the iteration is done or
+//failed.
+func (it *ScanIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.started && it.cursor == "0" {
+			return false
+		}
+		if !it.started {
+			it.started, it.cursor = true, "0"
+		}
+		it.cursor, it.page, it.err = it.fetch(it.cursor)
+	}
+
+	it.val, it.page = it.page[0], it.page[1:]
+	if it.pairs && len(it.page) > 0 {
+		it.hashVal, it.page = it.page[0], it.page[1:]
+	}
+	return true
+}
+
+//Val returns the current key for Scan, member for SScan or hash key for HScan.
+func (it *ScanIterator) Val() string {
+	return it.val
+}
+
+//HashValue returns the value of the current hash key for HScan.
+func (it *ScanIterator) HashValue() string {
+	return it.hashVal
+}
+
+//Err returns the error that stopped the iteration.
+func (it *ScanIterator) Err() error {
+	return it.err
+}
+
+//matchPattern returns true if s matches the glob style pattern the way redis matches it. *, ? and [...] classes with
+//ranges and ^ negation are supported and \ escapes the next character. An empty pattern matches everything.
+func matchPattern(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchPattern(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			n, ok := matchClass(pattern, s[0])
+			if !ok {
+				return false
+			}
+			pattern, s = pattern[n:], s[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+//matchClass matches c against the [...] class at the start of the pattern. It returns the length of the class and if
+//c matched. An unterminated class ends with the pattern.
+func matchClass(pattern string, c byte) (int, bool) {
+	i, not, match := 1, false, false
+	if i < len(pattern) && pattern[i] == '^' {
+		not = true
+		i++
+	}
+	for i < len(pattern) && pattern[i] != ']' {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			match = match || pattern[i+1] == c
+			i += 2
+		case i+2 < len(pattern) && pattern[i+1] == '-':
+			lo, hi := pattern[i], pattern[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			match = match || c >= lo && c <= hi
+			i += 3
+		default:
+			match = match || pattern[i] == c
+			i++
+		}
+	}
+	if i < len(pattern) {
+		i++
+	}
+	return i, match != not
+}
+
+//Scan returns an iterator over the keys matching the glob style pattern, an empty pattern matching every key. count
+//is a hint of how many keys to fetch per page. Keys changed during the iteration may be returned twice or not at all.
+func (r *Redis) Scan(pattern string, count int) *ScanIterator {
+	return r.ScanContext(context.Background(), pattern, count)
+}
+
+//ScanContext returns an iterator over the keys matching the pattern, fetching the pages with the context.
+func (r *Redis) ScanContext(ctx context.Context, pattern string, count int) *ScanIterator {
+	return r.scan(ctx, "Scan", "SCAN", "", pattern, count, false)
+}
+
+//HScan returns an iterator over the hash keys and values of the hash whose hash key matches the pattern.
+func (r *Redis) HScan(key string, pattern string, count int) *ScanIterator {
+	return r.HScanContext(context.Background(), key, pattern, count)
+}
+
+//HScanContext returns an iterator over the hash keys and values of the hash, fetching the pages with the context.
+func (r *Redis) HScanContext(ctx context.Context, key string, pattern string, count int) *ScanIterator {
+	return r.scan(ctx, "HScan", "HSCAN", key, pattern, count, true)
+}
+
+//SScan returns an iterator over the members of the set matching the pattern.
+func (r *Redis) SScan(key string, pattern string, count int) *ScanIterator {
+	return r.SScanContext(context.Background(), key, pattern, count)
+}
+
+//SScanContext returns an iterator over the members of the set, fetching the pages with the context.
+func (r *Redis) SScanContext(ctx context.Context, key string, pattern string, count int) *ScanIterator {
+	return r.scan(ctx, "SScan", "SSCAN", key, pattern, count, false)
+}
+
+//scan returns an iterator running the scan command, each page on its own pooled connection. Only SCAN is run without
+//a key.
+func (r *Redis) scan(ctx context.Context, op, cmd, key, pattern string, count int, pairs bool) *ScanIterator {
+	return &ScanIterator{
+		pairs: pairs,
+		fetch: func(cursor string) (string, []string, error) {
+			var args redis.Args
+			if cmd != "SCAN" {
+				args = args.Add(key)
+			}
+			args = args.Add(cursor)
+			if pattern != "" {
+				args = args.Add("MATCH", pattern)
+			}
+			if count > 0 {
+				args = args.Add("COUNT", count)
+			}
+
+			vals, err := redis.Values(r.do(ctx, cmd, args...))
+			if err != nil {
+				return "", nil, redisError(op, key, err)
+			}
+			var next string
+			var items []string
+			if _, err := redis.Scan(vals, &next, &items); err != nil {
+				return "", nil, opError(op, key, err)
+			}
+			return next, items, nil
+		},
+	}
+}
+
+//defaultScanCount is the page size of the memory store iterators when count isn't positive, the same as redis.
+const defaultScanCount = 10
+
+//memoryScan returns an iterator over the names returned by snapshot, a page of count names at a time. The snapshot
+//only copies the matching names under the lock when the iteration starts. Each page then takes the lock again to
+//fetch its items with page, which skips the names that are gone.
+func (m *MemoryStore) memoryScan(op, key string, count int, pairs bool, snapshot func() ([]string, error), page func(names []string) ([]string, error)) *ScanIterator {
+	if count <= 0 {
+		count = defaultScanCount
+	}
+	var names []string
+	return &ScanIterator{
+		pairs: pairs,
+		fetch: func(cursor string) (string, []string, error) {
+			start, _ := strconv.Atoi(cursor)
+			if start == 0 {
+				m.mu.Lock()
+				snapped, err := snapshot()
+				m.mu.Unlock()
+				if err != nil {
+					return "", nil, opError(op, key, err)
+				}
+				sort.Strings(snapped)
+				names = snapped
+			}
+
+			end := start + count
+			next := strconv.Itoa(end)
+			if end >= len(names) {
+				end, next = len(names), "0"
+			}
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			items, err := page(names[start:end])
+			if err != nil {
+				return "", nil, opError(op, key, err)
+			}
+			return next, items, nil
+		},
+	}
+}
+
+//Scan returns an iterator over the keys matching the glob style pattern, an empty pattern matching every key. The
+//names of the matching keys are copied when the iteration starts, which is O(n) under the lock, and then returned in
+//sorted pages of count keys. Keys deleted in between are skipped and keys added in between are not returned.
+func (m *MemoryStore) Scan(pattern string, count int) *ScanIterator {
+	return m.memoryScan("Scan", "", count, false, func() ([]string, error) {
+		var keys []string
+		for k := range m.data {
+			if m.lookup(k) != nil && matchPattern(pattern, k) {
+				keys = append(keys, k)
+			}
+		}
+		return keys, nil
+	}, func(names []string) ([]string, error) {
+		var keys []string
+		for _, k := range names {
+			if m.lookup(k) != nil {
+				keys = append(keys, k)
+			}
+		}
+		return keys, nil
+	})
+}
+
+//HScan returns an iterator over the hash keys and values of the hash whose hash key matches the pattern. The hash
+//keys are copied when the iteration starts and their values are fetched a page at a time.
+func (m *MemoryStore) HScan(key string, pattern string, count int) *ScanIterator {
+	return m.memoryScan("HScan", key, count, true, func() ([]string, error) {
+		h, err := m.hashValue(key, false)
+		if err != nil {
+			return nil, err
+		}
+		var hashes []string
+		for k := range h {
+			if matchPattern(pattern, k) {
+				hashes = append(hashes, k)
+			}
+		}
+		return hashes, nil
+	}, func(names []string) ([]string, error) {
+		h, err := m.hashValue(key, false)
+		if err != nil {
+			return nil, err
+		}
+		var pairs []string
+		for _, k := range names {
+			if v, ok := h[k]; ok {
+				pairs = append(pairs, k, v)
+			}
+		}
+		return pairs, nil
+	})
+}
+
+//SScan returns an iterator over the members of the set matching the pattern. The members are copied when the
+//iteration starts and returned a page at a time.
+func (m *MemoryStore) SScan(key string, pattern string, count int) *ScanIterator {
+	return m.memoryScan("SScan", key, count, false, func() ([]string, error) {
+		s, err := m.setValue(key, false)
+		if err != nil {
+			return nil, err
+		}
+		var members []string
+		for v := range s {
+			if matchPattern(pattern, v) {
+				members = append(members, v)
+			}
+		}
+		return members, nil
+	}, func(names []string) ([]string, error) {
+		s, err := m.setValue(key, false)
+		if err != nil {
+			return nil, err
+		}
+		var members []string
+		for _, v := range names {
+			if _, ok := s[v]; ok {
+				members = append(members, v)
+			}
+		}
+		return members, nil
+	})
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//Scripter is implemented by stores that can run Lua scripts on the server. A script runs atomically, no other command
+//runs while it does.
+type Scripter interface {
+	LoadScript(script *Script) error
+	LoadScriptContext(ctx context.Context, script *Script) error
+	Eval(script *Script, keys []string, args ...interface{}) (interface{}, error)
+	EvalContext(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error)
+}
+
+//Script is a Lua script. Create it once with NewScript and reuse it, Eval runs it by its SHA1 hash and only sends the
+//source when the server doesn't have it cached.
+type Script struct {
+	src    string
+	script *redis.Script
+}
+
+//NewScript returns the script with the Lua source.
+func NewScript(src string) *Script {
+	return &Script{
+		src:    src,
+		script: redis.NewScript(-1, src),
+	}
+}
+
+//LoadScript loads the script into the script cache of the server without running it.
+func (r *Redis) LoadScript(script *Script) error {
+	return r.LoadScriptContext(context.Background(), script)
+}
+
+//LoadScriptContext loads the script into the script cache of the server without running it.
+func (r *Redis) LoadScriptContext(ctx context.Context, script *Script) error {
+	_, err := r.do(ctx, "SCRIPT", "LOAD", script.src)
+	return redisError("LoadScript", "", err)
+}
+
+//Eval runs the script with the keys and arguments, available to the script as KEYS and ARGV. The script is run with
+//EVALSHA and falls back to EVAL when the server doesn't have it cached. Integers are returned as int64, strings as
+//[]byte, status replies as string, tables as []interface{} and nil or false as nil.
+func (r *Redis) Eval(script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	return r.EvalContext(context.Background(), script, keys, args...)
+}
+
+//EvalContext runs the script with the keys and arguments.
+func (r *Redis) EvalContext(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, opError("Eval", "", err)
+	}
+	defer conn.Close()
+
+	keysAndArgs := make([]interface{}, 0, len(keys)+len(args)+2)
+	keysAndArgs = append(keysAndArgs, len(keys))
+	for _, k := range keys {
+		keysAndArgs = append(keysAndArgs, k)
+	}
+	keysAndArgs = append(append(keysAndArgs, args...), contextArg{ctx})
+
+	v, err := script.script.Do(conn, keysAndArgs...)
+	if err != nil {
+		return nil, redisError("Eval", "", err)
+	}
+	return v, nil
+}
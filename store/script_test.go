@@ -0,0 +1,63 @@
+package store_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/awkhan/go-store/store"
+	"github.com/stretchr/testify/assert"
+)
+
+//decrementIfEnough decrements the counter at KEYS[1] by ARGV[1] unless that takes it below zero.
+const decrementIfEnough = `
+local n = tonumber(redis.call('GET', KEYS[1]) or '0')
+local by = tonumber(ARGV[1])
+if n < by then
+	return -1
+end
+redis.call('SET', KEYS[1], n - by)
+return n - by
+`
+
+//decrementIfEnoughFunc is the implementation of decrementIfEnough run by the fake server.
+func decrementIfEnoughFunc(db *store.MemoryStore, keys, args []string) (interface{}, error) {
+	n, err := db.GetInt64(keys[0])
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	by, _ := strconv.ParseInt(args[0], 10, 64)
+	if n < by {
+		return int64(-1), nil
+	}
+	return n - by, db.Set(keys[0], n-by)
+}
+
+func TestRedisEval(t *testing.T) {
+	if srv != nil {
+		srv.RegisterScript(decrementIfEnough, decrementIfEnoughFunc)
+	}
+	rs.ClearDataStore()
+
+	var sc store.Scripter = rs
+	script := store.NewScript(decrementIfEnough)
+
+	rs.Set("counter", 5)
+	v, err := sc.Eval(script, []string{"counter"}, 3)
+	assert.Nil(t, err, "Error running script %v", err)
+	assert.Equal(t, int64(2), v, "Invalid script reply")
+
+	v, err = sc.Eval(script, []string{"counter"}, 3)
+	assert.Nil(t, err, "Error running cached script %v", err)
+	assert.Equal(t, int64(-1), v, "Script should refuse to go below zero")
+
+	n, _ := rs.GetInt64("counter")
+	assert.Equal(t, int64(2), n, "Invalid value after script")
+
+	err = sc.LoadScript(script)
+	assert.Nil(t, err, "Error loading script %v", err)
+
+	rs.PushItemToList("list", "a", true)
+	_, err = sc.Eval(script, []string{"list"}, 1)
+	assert.NotNil(t, err, "Script on a list should fail")
+}
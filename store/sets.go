@@ -0,0 +1,342 @@
+package store
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//SetUnion returns the members of all the sets. Missing keys are treated as empty sets.
+func (r *Redis) SetUnion(keys ...string) ([]string, error) {
+	return r.SetUnionContext(context.Background(), keys...)
+}
+
+//SetUnionContext returns the members of all the sets.
+func (r *Redis) SetUnionContext(ctx context.Context, keys ...string) ([]string, error) {
+	return r.setOp(ctx, "SetUnion", "SUNION", keys)
+}
+
+//SetIntersect returns the members found in every one of the sets. Missing keys are treated as empty sets.
+func (r *Redis) SetIntersect(keys ...string) ([]string, error) {
+	return r.SetIntersectContext(context.Background(), keys...)
+}
+
+//SetIntersectContext returns the members found in every one of the sets.
+func (r *Redis) SetIntersectContext(ctx context.Context, keys ...string) ([]string, error) {
+	return r.setOp(ctx, "SetIntersect", "SINTER", keys)
+}
+
+//SetDiff returns the members of the first set that are not in any of the other sets. Missing keys are treated as
+//empty sets.
+func (r *Redis) SetDiff(keys ...string) ([]string, error) {
+	return r.SetDiffContext(context.Background(), keys...)
+}
+
+//SetDiffContext returns the members of the first set that are not in any of the other sets.
+func (r *Redis) SetDiffContext(ctx context.Context, keys ...string) ([]string, error) {
+	return r.setOp(ctx, "SetDiff", "SDIFF", keys)
+}
+
+//SetUnionStore stores the union of the sets in dst, replacing any value it holds, and returns the number of members
+//stored. dst is deleted when the result is empty.
+func (r *Redis) SetUnionStore(dst string, keys ...string) (int, error) {
+	return r.SetUnionStoreContext(context.Background(), dst, keys...)
+}
+
+//SetUnionStoreContext stores the union of the sets in dst and returns the number of members stored.
+func (r *Redis) SetUnionStoreContext(ctx context.Context, dst string, keys ...string) (int, error) {
+	return r.setOpStore(ctx, "SetUnionStore", "SUNIONSTORE", dst, keys)
+}
+
+//SetIntersectStore stores the intersection of the sets in dst, replacing any value it holds, and returns the number
+//of members stored. dst is deleted when the result is empty.
+func (r *Redis) SetIntersectStore(dst string, keys ...string) (int, error) {
+	return r.SetIntersectStoreContext(context.Background(), dst, keys...)
+}
+
+//SetIntersectStoreContext stores the intersection of the sets in dst and returns the number of members stored.
+func (r *Redis) SetIntersectStoreContext(ctx context.Context, dst string, keys ...string) (int, error) {
+	return r.setOpStore(ctx, "SetIntersectStore", "SINTERSTORE", dst, keys)
+}
+
+//SetDiffStore stores the difference of the sets in dst, replacing any value it holds, and returns the number of
+//members stored. dst is deleted when the result is empty.
+func (r *Redis) SetDiffStore(dst string, keys ...string) (int, error) {
+	return r.SetDiffStoreContext(context.Background(), dst, keys...)
+}
+
+//SetDiffStoreContext stores the difference of the sets in dst and returns the number of members stored.
+func (r *Redis) SetDiffStoreContext(ctx context.Context, dst string, keys ...string) (int, error) {
+	return r.setOpStore(ctx, "SetDiffStore", "SDIFFSTORE", dst, keys)
+}
+
+//setOp runs a set command over the keys and returns the resulting members.
+func (r *Redis) setOp(ctx context.Context, op, cmd string, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, opError(op, "", ErrNoKeys)
+	}
+	v, err := redis.Strings(r.do(ctx, cmd, redis.Args{}.AddFlat(keys)...))
+	return v, redisError(op, "", err)
+}
+
+//setOpStore runs a set command storing the result in dst and returns the number of members stored.
+func (r *Redis) setOpStore(ctx context.Context, op, cmd, dst string, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, opError(op, dst, ErrNoKeys)
+	}
+	n, err := redis.Int(r.do(ctx, cmd, redis.Args{}.Add(dst).AddFlat(keys)...))
+	return n, redisError(op, dst, err)
+}
+
+//SetCardinality returns the number of members of the set. Missing keys have no members.
+func (r *Redis) SetCardinality(key string) (int, error) {
+	return r.SetCardinalityContext(context.Background(), key)
+}
+
+//SetCardinalityContext returns the number of members of the set.
+func (r *Redis) SetCardinalityContext(ctx context.Context, key string) (int, error) {
+	n, err := redis.Int(r.do(ctx, "SCARD", key))
+	return n, redisError("SetCardinality", key, err)
+}
+
+//SetPop removes up to count random members from the set and returns them. An empty slice is returned for missing
+//keys.
+func (r *Redis) SetPop(key string, count int) ([]string, error) {
+	return r.SetPopContext(context.Background(), key, count)
+}
+
+//SetPopContext removes up to count random members from the set and returns them.
+func (r *Redis) SetPopContext(ctx context.Context, key string, count int) ([]string, error) {
+	if count < 0 {
+		return nil, opError("SetPop", key, ErrNegativeCount)
+	}
+	v, err := redis.Strings(r.do(ctx, "SPOP", key, count))
+	if err == redis.ErrNil {
+		return []string{}, nil
+	}
+	return v, redisError("SetPop", key, err)
+}
+
+//SetRandomMembers returns up to count distinct random members of the set without removing them. A negative count
+//returns exactly -count members which may repeat.
+func (r *Redis) SetRandomMembers(key string, count int) ([]string, error) {
+	return r.SetRandomMembersContext(context.Background(), key, count)
+}
+
+//SetRandomMembersContext returns up to count random members of the set without removing them.
+func (r *Redis) SetRandomMembersContext(ctx context.Context, key string, count int) ([]string, error) {
+	v, err := redis.Strings(r.do(ctx, "SRANDMEMBER", key, count))
+	return v, redisError("SetRandomMembers", key, err)
+}
+
+//sets returns the sets stored at the keys, nil for missing keys. The caller must hold the lock.
+func (m *MemoryStore) sets(keys []string) ([]map[string]struct{}, error) {
+	sets := make([]map[string]struct{}, len(keys))
+	for i, k := range keys {
+		s, err := m.setValue(k, false)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = s
+	}
+	return sets, nil
+}
+
+//unionSets returns the members of all the sets.
+func unionSets(sets []map[string]struct{}) map[string]struct{} {
+	result := make(map[string]struct{})
+	for _, s := range sets {
+		for v := range s {
+			result[v] = struct{}{}
+		}
+	}
+	return result
+}
+
+//intersectSets returns the members found in every one of the sets.
+func intersectSets(sets []map[string]struct{}) map[string]struct{} {
+	result := make(map[string]struct{})
+	for v := range sets[0] {
+		found := true
+		for _, s := range sets[1:] {
+			if _, found = s[v]; !found {
+				break
+			}
+		}
+		if found {
+			result[v] = struct{}{}
+		}
+	}
+	return result
+}
+
+//diffSets returns the members of the first set that are not in any of the other sets.
+func diffSets(sets []map[string]struct{}) map[string]struct{} {
+	result := make(map[string]struct{})
+	for v := range sets[0] {
+		found := false
+		for _, s := range sets[1:] {
+			if _, found = s[v]; found {
+				break
+			}
+		}
+		if !found {
+			result[v] = struct{}{}
+		}
+	}
+	return result
+}
+
+//setMembers returns the members of the set as a slice.
+func setMembers(s map[string]struct{}) []string {
+	members := make([]string, 0, len(s))
+	for v := range s {
+		members = append(members, v)
+	}
+	return members
+}
+
+//setOp combines the sets stored at the keys and returns the resulting members.
+func (m *MemoryStore) setOp(op string, keys []string, combine func([]map[string]struct{}) map[string]struct{}) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, opError(op, "", ErrNoKeys)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sets, err := m.sets(keys)
+	if err != nil {
+		return nil, opError(op, "", err)
+	}
+	return setMembers(combine(sets)), nil
+}
+
+//setOpStore combines the sets stored at the keys, stores the result in dst and returns the number of members stored.
+func (m *MemoryStore) setOpStore(op, dst string, keys []string, combine func([]map[string]struct{}) map[string]struct{}) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setOpStoreLocked(op, dst, keys, combine)
+}
+
+//setOpStoreLocked is setOpStore for callers holding the lock.
+func (m *MemoryStore) setOpStoreLocked(op, dst string, keys []string, combine func([]map[string]struct{}) map[string]struct{}) (int, error) {
+	if len(keys) == 0 {
+		return 0, opError(op, dst, ErrNoKeys)
+	}
+	sets, err := m.sets(keys)
+	if err != nil {
+		return 0, opError(op, dst, err)
+	}
+	result := combine(sets)
+	if len(result) == 0 {
+		m.deleteEntry(dst)
+	} else {
+		m.storeEntry(dst, &memoryEntry{value: result})
+	}
+	return len(result), nil
+}
+
+//SetUnion returns the members of all the sets. Missing keys are treated as empty sets.
+func (m *MemoryStore) SetUnion(keys ...string) ([]string, error) {
+	return m.setOp("SetUnion", keys, unionSets)
+}
+
+//SetIntersect returns the members found in every one of the sets. Missing keys are treated as empty sets.
+func (m *MemoryStore) SetIntersect(keys ...string) ([]string, error) {
+	return m.setOp("SetIntersect", keys, intersectSets)
+}
+
+//SetDiff returns the members of the first set that are not in any of the other sets. Missing keys are treated as
+//empty sets.
+func (m *MemoryStore) SetDiff(keys ...string) ([]string, error) {
+	return m.setOp("SetDiff", keys, diffSets)
+}
+
+//SetUnionStore stores the union of the sets in dst, replacing any value it holds, and returns the number of members
+//stored. dst is deleted when the result is empty.
+func (m *MemoryStore) SetUnionStore(dst string, keys ...string) (int, error) {
+	return m.setOpStore("SetUnionStore", dst, keys, unionSets)
+}
+
+//SetIntersectStore stores the intersection of the sets in dst, replacing any value it holds, and returns the number
+//of members stored. dst is deleted when the result is empty.
+func (m *MemoryStore) SetIntersectStore(dst string, keys ...string) (int, error) {
+	return m.setOpStore("SetIntersectStore", dst, keys, intersectSets)
+}
+
+//SetDiffStore stores the difference of the sets in dst, replacing any value it holds, and returns the number of
+//members stored. dst is deleted when the result is empty.
+func (m *MemoryStore) SetDiffStore(dst string, keys ...string) (int, error) {
+	return m.setOpStore("SetDiffStore", dst, keys, diffSets)
+}
+
+//SetCardinality returns the number of members of the set. Missing keys have no members.
+func (m *MemoryStore) SetCardinality(key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.setValue(key, false)
+	return len(s), opError("SetCardinality", key, err)
+}
+
+//SetPop removes up to count random members from the set and returns them. An empty slice is returned for missing
+//keys.
+func (m *MemoryStore) SetPop(key string, count int) ([]string, error) {
+	if count < 0 {
+		return nil, opError("SetPop", key, ErrNegativeCount)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.setValue(key, false)
+	if err != nil {
+		return nil, opError("SetPop", key, err)
+	}
+
+	members := setMembers(s)
+	rand.Shuffle(len(members), func(i, j int) {
+		members[i], members[j] = members[j], members[i]
+	})
+	if count < len(members) {
+		members = members[:count]
+	}
+	for _, v := range members {
+		delete(s, v)
+	}
+	if len(members) > 0 {
+		m.modified(key)
+	}
+	if s != nil && len(s) == 0 {
+		m.deleteEntry(key)
+	}
+	return members, nil
+}
+
+//SetRandomMembers returns up to count distinct random members of the set without removing them. A negative count
+//returns exactly -count members which may repeat.
+func (m *MemoryStore) SetRandomMembers(key string, count int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.setValue(key, false)
+	if err != nil {
+		return nil, opError("SetRandomMembers", key, err)
+	}
+
+	members := setMembers(s)
+	if count < 0 {
+		if len(members) == 0 {
+			return []string{}, nil
+		}
+		picked := make([]string, -count)
+		for i := range picked {
+			picked[i] = members[rand.Intn(len(members))]
+		}
+		return picked, nil
+	}
+
+	rand.Shuffle(len(members), func(i, j int) {
+		members[i], members[j] = members[j], members[i]
+	})
+	if count < len(members) {
+		members = members[:count]
+	}
+	return members, nil
+}
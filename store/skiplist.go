@@ -0,0 +1,217 @@
+package store
+
+import "math/rand"
+
+const (
+	//skiplistMaxLevel bounds the height of the nodes, which is plenty for 2^64 members with skiplistP.
+	skiplistMaxLevel = 32
+	//skiplistP is the probability of a node reaching the next level.
+	skiplistP = 0.25
+)
+
+//skiplistLevel is a forward link of a node along with the number of nodes it skips.
+type skiplistLevel struct {
+	forward *skiplistNode
+	span    int
+}
+
+//skiplistNode holds a member of a sorted set and its score.
+type skiplistNode struct {
+	member string
+	score  float64
+	levels []skiplistLevel
+}
+
+//less returns true if the node sorts before the score and member.
+func (n *skiplistNode) less(score float64, member string) bool {
+	return n.score < score || n.score == score && n.member < member
+}
+
+//next returns the node that follows in order, nil at the end of the list.
+func (n *skiplistNode) next() *skiplistNode {
+	return n.levels[0].forward
+}
+
+//skiplist keeps the members of a sorted set ordered by score and then by member, the same way redis does. The spans
+//of the links make finding the rank of a member and the member at a rank O(log n) as well as seeking to a score.
+type skiplist struct {
+	head   *skiplistNode
+	length int
+	level  int
+}
+
+//newSkiplist returns an empty skiplist.
+func newSkiplist() *skiplist {
+	return &skiplist{
+		head:  &skiplistNode{levels: make([]skiplistLevel, skiplistMaxLevel)},
+		level: 1,
+	}
+}
+
+//randomLevel returns the height of a new node.
+func randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && rand.Float64() < skiplistP {
+		level++
+	}
+	return level
+}
+
+//insert adds the member with the score. The member must not be in the list already.
+func (l *skiplist) insert(score float64, member string) {
+	var update [skiplistMaxLevel]*skiplistNode
+	var rank [skiplistMaxLevel]int
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].forward != nil && x.levels[i].forward.less(score, member) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			update[i] = l.head
+			update[i].levels[i].span = l.length
+		}
+		l.level = level
+	}
+
+	n := &skiplistNode{member: member, score: score, levels: make([]skiplistLevel, level)}
+	for i := 0; i < level; i++ {
+		n.levels[i].forward = update[i].levels[i].forward
+		update[i].levels[i].forward = n
+		n.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < l.level; i++ {
+		update[i].levels[i].span++
+	}
+	l.length++
+}
+
+//delete removes the member with the score. It returns false if it isn't in the list.
+func (l *skiplist) delete(score float64, member string) bool {
+	var update [skiplistMaxLevel]*skiplistNode
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.less(score, member) {
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	x = x.next()
+	if x == nil || x.score != score || x.member != member {
+		return false
+	}
+	for i := 0; i < l.level; i++ {
+		if update[i].levels[i].forward == x {
+			update[i].levels[i].span += x.levels[i].span - 1
+			update[i].levels[i].forward = x.levels[i].forward
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+	for l.level > 1 && l.head.levels[l.level-1].forward == nil {
+		l.level--
+	}
+	l.length--
+	return true
+}
+
+//seek returns the first node for which before returns false along with its zero based rank. before must return true
+//for a prefix of the list only. The node is nil when before is true for every node.
+func (l *skiplist) seek(before func(n *skiplistNode) bool) (*skiplistNode, int) {
+	rank := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && before(x.levels[i].forward) {
+			rank += x.levels[i].span
+			x = x.levels[i].forward
+		}
+	}
+	return x.next(), rank
+}
+
+//rank returns the zero based rank of the member with the score, or -1 if it isn't in the list.
+func (l *skiplist) rank(score float64, member string) int {
+	n, rank := l.seek(func(n *skiplistNode) bool {
+		return n.less(score, member)
+	})
+	if n == nil || n.score != score || n.member != member {
+		return -1
+	}
+	return rank
+}
+
+//byRank returns the node at the zero based rank, nil if the rank is out of range.
+func (l *skiplist) byRank(rank int) *skiplistNode {
+	if rank < 0 || rank >= l.length {
+		return nil
+	}
+	traversed := 0
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && traversed+x.levels[i].span <= rank+1 {
+			traversed += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if traversed == rank+1 {
+			return x
+		}
+	}
+	return nil
+}
+
+//sortedSet is a sorted set held by the memory store. The scores are kept in a map for constant time lookups and in a
+//skiplist for ordered access.
+type sortedSet struct {
+	scores map[string]float64
+	list   *skiplist
+}
+
+//newSortedSet returns an empty sorted set.
+func newSortedSet() *sortedSet {
+	return &sortedSet{
+		scores: make(map[string]float64),
+		list:   newSkiplist(),
+	}
+}
+
+//set adds the member or updates its score.
+func (z *sortedSet) set(member string, score float64) {
+	if old, ok := z.scores[member]; ok {
+		if old == score {
+			return
+		}
+		z.list.delete(old, member)
+	}
+	z.scores[member] = score
+	z.list.insert(score, member)
+}
+
+//remove removes the member. It returns false if it isn't in the set.
+func (z *sortedSet) remove(member string) bool {
+	score, ok := z.scores[member]
+	if !ok {
+		return false
+	}
+	delete(z.scores, member)
+	z.list.delete(score, member)
+	return true
+}
+
+//clone returns a deep copy of the set.
+func (z *sortedSet) clone() *sortedSet {
+	c := newSortedSet()
+	for n := z.list.head.next(); n != nil; n = n.next() {
+		c.set(n.member, n.score)
+	}
+	return c
+}
@@ -0,0 +1,587 @@
+package store
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//ZMember is a member of a sorted set and its score.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+//ZAddOptions are the conditions under which ZAdd adds or updates members. NX and XX can't be combined, nor can GT and
+//LT, and neither GT nor LT can be combined with NX.
+type ZAddOptions struct {
+	//NX only adds new members and never updates existing ones.
+	NX bool
+	//XX only updates existing members and never adds new ones.
+	XX bool
+	//GT only updates existing members when the new score is greater than the current one.
+	GT bool
+	//LT only updates existing members when the new score is less than the current one.
+	LT bool
+}
+
+//valid returns true if the options can be combined.
+func (o ZAddOptions) valid() bool {
+	return !(o.NX && o.XX) && !(o.GT && o.LT) && !(o.NX && (o.GT || o.LT))
+}
+
+//ZRangeBy selects the members of a sorted set between Min and Max. Score bounds are numbers, -inf or +inf and lex
+//bounds start with [ or with - and + for the lowest and highest members. A bound starting with ( excludes the bound
+//itself. Offset skips members of the range and a positive Count limits the number of members returned.
+type ZRangeBy struct {
+	Min, Max string
+	Offset   int
+	Count    int
+}
+
+//limit returns the LIMIT option of the range, nil when the range is not limited.
+func (by ZRangeBy) limit() redis.Args {
+	if by.Offset == 0 && by.Count <= 0 {
+		return nil
+	}
+	count := by.Count
+	if count <= 0 {
+		count = -1
+	}
+	return redis.Args{"LIMIT", by.Offset, count}
+}
+
+//ZAdd adds the members to the sorted set or updates their scores, subject to the options, and returns the number of
+//members added.
+func (r *Redis) ZAdd(key string, opts ZAddOptions, members ...ZMember) (int, error) {
+	return r.ZAddContext(context.Background(), key, opts, members...)
+}
+
+//ZAddContext adds the members to the sorted set or updates their scores and returns the number of members added.
+func (r *Redis) ZAddContext(ctx context.Context, key string, opts ZAddOptions, members ...ZMember) (int, error) {
+	if !opts.valid() {
+		return 0, opError("ZAdd", key, ErrZAddOptions)
+	}
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	n, err := redis.Int(r.do(ctx, "ZADD", zaddArgs(key, opts, members)...))
+	return n, redisError("ZAdd", key, err)
+}
+
+//zaddArgs returns the arguments of ZADD for the options and members.
+func zaddArgs(key string, opts ZAddOptions, members []ZMember) redis.Args {
+	args := redis.Args{key}
+	if opts.NX {
+		args = args.Add("NX")
+	}
+	if opts.XX {
+		args = args.Add("XX")
+	}
+	if opts.GT {
+		args = args.Add("GT")
+	}
+	if opts.LT {
+		args = args.Add("LT")
+	}
+	for _, m := range members {
+		args = args.Add(m.Score, m.Member)
+	}
+	return args
+}
+
+//ZIncrBy adds n to the score of the member and returns the new score. A missing member starts at zero.
+func (r *Redis) ZIncrBy(key string, member string, n float64) (float64, error) {
+	return r.ZIncrByContext(context.Background(), key, member, n)
+}
+
+//ZIncrByContext adds n to the score of the member and returns the new score.
+func (r *Redis) ZIncrByContext(ctx context.Context, key string, member string, n float64) (float64, error) {
+	v, err := redis.Float64(r.do(ctx, "ZINCRBY", key, n, member))
+	return v, redisError("ZIncrBy", key, err)
+}
+
+//ZScore returns the score of the member. ErrNotFound is returned when the member doesn't exist.
+func (r *Redis) ZScore(key string, member string) (float64, error) {
+	return r.ZScoreContext(context.Background(), key, member)
+}
+
+//ZScoreContext returns the score of the member.
+func (r *Redis) ZScoreContext(ctx context.Context, key string, member string) (float64, error) {
+	v, err := redis.Float64(r.do(ctx, "ZSCORE", key, member))
+	return v, redisError("ZScore", key, err)
+}
+
+//ZRank returns the zero based rank of the member ordered from the lowest score. ErrNotFound is returned when the
+//member doesn't exist.
+func (r *Redis) ZRank(key string, member string) (int, error) {
+	return r.ZRankContext(context.Background(), key, member)
+}
+
+//ZRankContext returns the zero based rank of the member ordered from the lowest score.
+func (r *Redis) ZRankContext(ctx context.Context, key string, member string) (int, error) {
+	v, err := redis.Int(r.do(ctx, "ZRANK", key, member))
+	return v, redisError("ZRank", key, err)
+}
+
+//ZRevRank returns the zero based rank of the member ordered from the highest score. ErrNotFound is returned when the
+//member doesn't exist.
+func (r *Redis) ZRevRank(key string, member string) (int, error) {
+	return r.ZRevRankContext(context.Background(), key, member)
+}
+
+//ZRevRankContext returns the zero based rank of the member ordered from the highest score.
+func (r *Redis) ZRevRankContext(ctx context.Context, key string, member string) (int, error) {
+	v, err := redis.Int(r.do(ctx, "ZREVRANK", key, member))
+	return v, redisError("ZRevRank", key, err)
+}
+
+//ZRangeByScore returns the members with a score in the range along with their scores, ordered from the lowest score.
+func (r *Redis) ZRangeByScore(key string, by ZRangeBy) ([]ZMember, error) {
+	return r.ZRangeByScoreContext(context.Background(), key, by)
+}
+
+//ZRangeByScoreContext returns the members with a score in the range along with their scores.
+func (r *Redis) ZRangeByScoreContext(ctx context.Context, key string, by ZRangeBy) ([]ZMember, error) {
+	args := append(redis.Args{key, by.Min, by.Max, "WITHSCORES"}, by.limit()...)
+	vals, err := redis.Strings(r.do(ctx, "ZRANGEBYSCORE", args...))
+	if err != nil {
+		return nil, redisError("ZRangeByScore", key, err)
+	}
+	members := make([]ZMember, len(vals)/2)
+	for i := range members {
+		score, err := strconv.ParseFloat(vals[2*i+1], 64)
+		if err != nil {
+			return nil, opError("ZRangeByScore", key, err)
+		}
+		members[i] = ZMember{Member: vals[2*i], Score: score}
+	}
+	return members, nil
+}
+
+//ZRangeByLex returns the members in the lex range. All the members of the sorted set must have the same score for
+//the result to be meaningful.
+func (r *Redis) ZRangeByLex(key string, by ZRangeBy) ([]string, error) {
+	return r.ZRangeByLexContext(context.Background(), key, by)
+}
+
+//ZRangeByLexContext returns the members in the lex range.
+func (r *Redis) ZRangeByLexContext(ctx context.Context, key string, by ZRangeBy) ([]string, error) {
+	args := append(redis.Args{key, by.Min, by.Max}, by.limit()...)
+	v, err := redis.Strings(r.do(ctx, "ZRANGEBYLEX", args...))
+	return v, redisError("ZRangeByLex", key, err)
+}
+
+//ZRem removes the members from the sorted set and returns the number of members removed.
+func (r *Redis) ZRem(key string, members ...string) (int, error) {
+	return r.ZRemContext(context.Background(), key, members...)
+}
+
+//ZRemContext removes the members from the sorted set and returns the number of members removed.
+func (r *Redis) ZRemContext(ctx context.Context, key string, members ...string) (int, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	n, err := redis.Int(r.do(ctx, "ZREM", redis.Args{key}.AddFlat(members)...))
+	return n, redisError("ZRem", key, err)
+}
+
+//ZRemRangeByScore removes the members with a score between min and max and returns the number of members removed.
+//The bounds use the syntax of ZRangeBy.
+func (r *Redis) ZRemRangeByScore(key string, min, max string) (int, error) {
+	return r.ZRemRangeByScoreContext(context.Background(), key, min, max)
+}
+
+//ZRemRangeByScoreContext removes the members with a score between min and max.
+func (r *Redis) ZRemRangeByScoreContext(ctx context.Context, key string, min, max string) (int, error) {
+	n, err := redis.Int(r.do(ctx, "ZREMRANGEBYSCORE", key, min, max))
+	return n, redisError("ZRemRangeByScore", key, err)
+}
+
+//ZCard returns the number of members of the sorted set. Missing keys have no members.
+func (r *Redis) ZCard(key string) (int, error) {
+	return r.ZCardContext(context.Background(), key)
+}
+
+//ZCardContext returns the number of members of the sorted set.
+func (r *Redis) ZCardContext(ctx context.Context, key string) (int, error) {
+	n, err := redis.Int(r.do(ctx, "ZCARD", key))
+	return n, redisError("ZCard", key, err)
+}
+
+//scoreBound is an end of a score range.
+type scoreBound struct {
+	value     float64
+	exclusive bool
+}
+
+//parseScoreBound parses a score bound such as 1.5, (1.5, -inf or +inf.
+func parseScoreBound(s string) (scoreBound, error) {
+	var b scoreBound
+	if strings.HasPrefix(s, "(") {
+		b.exclusive, s = true, s[1:]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) {
+		return b, ErrScoreRange
+	}
+	b.value = v
+	return b, nil
+}
+
+//scoreRange returns functions reporting if a node is below the min or above the max of the range.
+func scoreRange(min, max string) (func(*skiplistNode) bool, func(*skiplistNode) bool, error) {
+	lo, err := parseScoreBound(min)
+	if err != nil {
+		return nil, nil, err
+	}
+	hi, err := parseScoreBound(max)
+	if err != nil {
+		return nil, nil, err
+	}
+	below := func(n *skiplistNode) bool {
+		return n.score < lo.value || lo.exclusive && n.score == lo.value
+	}
+	above := func(n *skiplistNode) bool {
+		return n.score > hi.value || hi.exclusive && n.score == hi.value
+	}
+	return below, above, nil
+}
+
+//lexBound is an end of a lex range. inf is -1 for - and 1 for +.
+type lexBound struct {
+	value     string
+	exclusive bool
+	inf       int
+}
+
+//parseLexBound parses a lex bound such as [a, (a, - or +.
+func parseLexBound(s string) (lexBound, error) {
+	switch {
+	case s == "-":
+		return lexBound{inf: -1}, nil
+	case s == "+":
+		return lexBound{inf: 1}, nil
+	case strings.HasPrefix(s, "["):
+		return lexBound{value: s[1:]}, nil
+	case strings.HasPrefix(s, "("):
+		return lexBound{value: s[1:], exclusive: true}, nil
+	}
+	return lexBound{}, ErrLexRange
+}
+
+//lexRange returns functions reporting if a node is below the min or above the max of the range.
+func lexRange(min, max string) (func(*skiplistNode) bool, func(*skiplistNode) bool, error) {
+	lo, err := parseLexBound(min)
+	if err != nil {
+		return nil, nil, err
+	}
+	hi, err := parseLexBound(max)
+	if err != nil {
+		return nil, nil, err
+	}
+	below := func(n *skiplistNode) bool {
+		if lo.inf != 0 {
+			return lo.inf > 0
+		}
+		return n.member < lo.value || lo.exclusive && n.member == lo.value
+	}
+	above := func(n *skiplistNode) bool {
+		if hi.inf != 0 {
+			return hi.inf < 0
+		}
+		return n.member > hi.value || hi.exclusive && n.member == hi.value
+	}
+	return below, above, nil
+}
+
+//sortedSetValue returns the sorted set stored at key, creating it when create is set. The caller must hold the lock.
+func (m *MemoryStore) sortedSetValue(key string, create bool) (*sortedSet, error) {
+	e := m.lookup(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		z := newSortedSet()
+		m.storeEntry(key, &memoryEntry{value: z})
+		return z, nil
+	}
+	z, ok := e.value.(*sortedSet)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	return z, nil
+}
+
+//rangeNodes returns the nodes of the sorted set between the bounds reported by below and above, applying the offset
+//and count of the range. The caller must hold the lock.
+func (z *sortedSet) rangeNodes(by ZRangeBy, below, above func(*skiplistNode) bool) []*skiplistNode {
+	if z == nil || by.Offset < 0 {
+		return nil
+	}
+	n, rank := z.list.seek(below)
+	if by.Offset > 0 {
+		n = z.list.byRank(rank + by.Offset)
+	}
+
+	var nodes []*skiplistNode
+	for ; n != nil && !above(n); n = n.next() {
+		if by.Count > 0 && len(nodes) == by.Count {
+			break
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+//ZAdd adds the members to the sorted set or updates their scores, subject to the options, and returns the number of
+//members added.
+func (m *MemoryStore) ZAdd(key string, opts ZAddOptions, members ...ZMember) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.zaddLocked(key, opts, members)
+}
+
+//zaddLocked is ZAdd for callers holding the lock.
+func (m *MemoryStore) zaddLocked(key string, opts ZAddOptions, members []ZMember) (int, error) {
+	if !opts.valid() {
+		return 0, opError("ZAdd", key, ErrZAddOptions)
+	}
+	for _, zm := range members {
+		if math.IsNaN(zm.Score) {
+			return 0, opError("ZAdd", key, ErrNotFloat)
+		}
+	}
+
+	z, err := m.sortedSetValue(key, false)
+	if err != nil {
+		return 0, opError("ZAdd", key, err)
+	}
+	if z == nil {
+		if opts.XX || len(members) == 0 {
+			return 0, nil
+		}
+		z, _ = m.sortedSetValue(key, true)
+	}
+
+	added := 0
+	for _, zm := range members {
+		old, ok := z.scores[zm.Member]
+		switch {
+		case !ok && opts.XX, ok && opts.NX:
+			continue
+		case ok && opts.GT && zm.Score <= old, ok && opts.LT && zm.Score >= old:
+			continue
+		}
+		if !ok {
+			added++
+		}
+		z.set(zm.Member, zm.Score)
+		m.modified(key)
+	}
+	return added, nil
+}
+
+//ZIncrBy adds n to the score of the member and returns the new score. A missing member starts at zero.
+func (m *MemoryStore) ZIncrBy(key string, member string, n float64) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.zincrByLocked(key, member, n)
+}
+
+//zincrByLocked is ZIncrBy for callers holding the lock.
+func (m *MemoryStore) zincrByLocked(key string, member string, n float64) (float64, error) {
+	if math.IsNaN(n) {
+		return 0, opError("ZIncrBy", key, ErrNotFloat)
+	}
+	z, err := m.sortedSetValue(key, false)
+	if err != nil {
+		return 0, opError("ZIncrBy", key, err)
+	}
+
+	var score float64
+	if z != nil {
+		score = z.scores[member]
+	}
+	score += n
+	if math.IsNaN(score) {
+		return 0, opError("ZIncrBy", key, ErrScoreNaN)
+	}
+	if z == nil {
+		z, _ = m.sortedSetValue(key, true)
+	}
+	z.set(member, score)
+	m.modified(key)
+	return score, nil
+}
+
+//ZScore returns the score of the member. ErrNotFound is returned when the member doesn't exist.
+func (m *MemoryStore) ZScore(key string, member string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.zscoreLocked(key, member)
+}
+
+//zscoreLocked is ZScore for callers holding the lock.
+func (m *MemoryStore) zscoreLocked(key string, member string) (float64, error) {
+	z, err := m.sortedSetValue(key, false)
+	if err != nil {
+		return 0, opError("ZScore", key, err)
+	}
+	if z == nil {
+		return 0, opError("ZScore", key, ErrNotFound)
+	}
+	score, ok := z.scores[member]
+	if !ok {
+		return 0, opError("ZScore", key, ErrNotFound)
+	}
+	return score, nil
+}
+
+//rank returns the zero based rank of the member counted from the lowest or the highest score.
+func (m *MemoryStore) rank(op, key, member string, rev bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	z, err := m.sortedSetValue(key, false)
+	if err != nil {
+		return 0, opError(op, key, err)
+	}
+	if z == nil {
+		return 0, opError(op, key, ErrNotFound)
+	}
+	score, ok := z.scores[member]
+	if !ok {
+		return 0, opError(op, key, ErrNotFound)
+	}
+	rank := z.list.rank(score, member)
+	if rev {
+		rank = z.list.length - 1 - rank
+	}
+	return rank, nil
+}
+
+//ZRank returns the zero based rank of the member ordered from the lowest score. ErrNotFound is returned when the
+//member doesn't exist.
+func (m *MemoryStore) ZRank(key string, member string) (int, error) {
+	return m.rank("ZRank", key, member, false)
+}
+
+//ZRevRank returns the zero based rank of the member ordered from the highest score. ErrNotFound is returned when the
+//member doesn't exist.
+func (m *MemoryStore) ZRevRank(key string, member string) (int, error) {
+	return m.rank("ZRevRank", key, member, true)
+}
+
+//ZRangeByScore returns the members with a score in the range along with their scores, ordered from the lowest score.
+func (m *MemoryStore) ZRangeByScore(key string, by ZRangeBy) ([]ZMember, error) {
+	below, above, err := scoreRange(by.Min, by.Max)
+	if err != nil {
+		return nil, opError("ZRangeByScore", key, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	z, err := m.sortedSetValue(key, false)
+	if err != nil {
+		return nil, opError("ZRangeByScore", key, err)
+	}
+
+	nodes := z.rangeNodes(by, below, above)
+	members := make([]ZMember, len(nodes))
+	for i, n := range nodes {
+		members[i] = ZMember{Member: n.member, Score: n.score}
+	}
+	return members, nil
+}
+
+//ZRangeByLex returns the members in the lex range. All the members of the sorted set must have the same score for
+//the result to be meaningful.
+func (m *MemoryStore) ZRangeByLex(key string, by ZRangeBy) ([]string, error) {
+	below, above, err := lexRange(by.Min, by.Max)
+	if err != nil {
+		return nil, opError("ZRangeByLex", key, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	z, err := m.sortedSetValue(key, false)
+	if err != nil {
+		return nil, opError("ZRangeByLex", key, err)
+	}
+
+	nodes := z.rangeNodes(by, below, above)
+	members := make([]string, len(nodes))
+	for i, n := range nodes {
+		members[i] = n.member
+	}
+	return members, nil
+}
+
+//removeMembers removes the members from the sorted set stored at key, deleting the key once it is empty. The caller
+//must hold the lock.
+func (m *MemoryStore) removeMembers(key string, z *sortedSet, members []string) int {
+	n := 0
+	for _, member := range members {
+		if z.remove(member) {
+			n++
+		}
+	}
+	if n > 0 {
+		m.modified(key)
+	}
+	if len(z.scores) == 0 {
+		m.deleteEntry(key)
+	}
+	return n
+}
+
+//ZRem removes the members from the sorted set and returns the number of members removed.
+func (m *MemoryStore) ZRem(key string, members ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.zremLocked(key, members)
+}
+
+//zremLocked is ZRem for callers holding the lock.
+func (m *MemoryStore) zremLocked(key string, members []string) (int, error) {
+	z, err := m.sortedSetValue(key, false)
+	if err != nil || z == nil {
+		return 0, opError("ZRem", key, err)
+	}
+	return m.removeMembers(key, z, members), nil
+}
+
+//ZRemRangeByScore removes the members with a score between min and max and returns the number of members removed.
+//The bounds use the syntax of ZRangeBy.
+func (m *MemoryStore) ZRemRangeByScore(key string, min, max string) (int, error) {
+	below, above, err := scoreRange(min, max)
+	if err != nil {
+		return 0, opError("ZRemRangeByScore", key, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	z, err := m.sortedSetValue(key, false)
+	if err != nil || z == nil {
+		return 0, opError("ZRemRangeByScore", key, err)
+	}
+
+	nodes := z.rangeNodes(ZRangeBy{}, below, above)
+	members := make([]string, len(nodes))
+	for i, n := range nodes {
+		members[i] = n.member
+	}
+	return m.removeMembers(key, z, members), nil
+}
+
+//ZCard returns the number of members of the sorted set. Missing keys have no members.
+func (m *MemoryStore) ZCard(key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	z, err := m.sortedSetValue(key, false)
+	if err != nil || z == nil {
+		return 0, opError("ZCard", key, err)
+	}
+	return len(z.scores), nil
+}
@@ -1,5 +1,12 @@
 package store
 
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"time"
+)
+
 const (
 	//DataTypeString is a string data type.
 	DataTypeString = iota
@@ -9,30 +16,260 @@ const (
 	DataTypeInt
 	//DataTypeInt64 is a int64 data type.
 	DataTypeInt64
+	//DataTypeFloat64 is a float64 data type.
+	DataTypeFloat64
+	//DataTypeBytes is a []byte data type.
+	DataTypeBytes
+	//DataTypeTime is a time.Time data type, stored as RFC3339 or as unix seconds.
+	DataTypeTime
 )
 
+//itemTypes are the Go types the data types are converted to.
+var itemTypes = map[int]reflect.Type{
+	DataTypeString:  reflect.TypeOf(""),
+	DataTypeBool:    reflect.TypeOf(false),
+	DataTypeInt:     reflect.TypeOf(0),
+	DataTypeInt64:   reflect.TypeOf(int64(0)),
+	DataTypeFloat64: reflect.TypeOf(float64(0)),
+	DataTypeBytes:   reflect.TypeOf([]byte(nil)),
+	DataTypeTime:    reflect.TypeOf(time.Time{}),
+}
+
+//validDataType returns true if the data type is one of the supported data types.
+func validDataType(dataType int) bool {
+	_, ok := itemTypes[dataType]
+	return ok
+}
+
+//convertItem converts a stored string to the requested data type the same way the redigo reply helpers do.
+func convertItem(v string, dataType int) (interface{}, error) {
+	switch dataType {
+	case DataTypeString:
+		return v, nil
+	case DataTypeBool:
+		return strconv.ParseBool(v)
+	case DataTypeInt:
+		n, err := strconv.ParseInt(v, 10, 0)
+		return int(n), err
+	case DataTypeInt64:
+		return strconv.ParseInt(v, 10, 64)
+	case DataTypeFloat64:
+		return strconv.ParseFloat(v, 64)
+	case DataTypeBytes:
+		return []byte(v), nil
+	case DataTypeTime:
+		return parseTime(v)
+	default:
+		return nil, ErrInvalidDataType
+	}
+}
+
+//convertItems converts stored strings to a slice of the requested data type, such as []bool for DataTypeBool.
+func convertItems(vals []string, dataType int) (interface{}, error) {
+	t, ok := itemTypes[dataType]
+	if !ok {
+		return nil, ErrInvalidDataType
+	}
+	items := reflect.MakeSlice(reflect.SliceOf(t), len(vals), len(vals))
+	for i, v := range vals {
+		item, err := convertItem(v, dataType)
+		if err != nil {
+			return nil, err
+		}
+		items.Index(i).Set(reflect.ValueOf(item))
+	}
+	return items.Interface(), nil
+}
+
 //Store represents an interface associated with NO SQL databases
 type Store interface {
-	DeleteKey(key string) error
+	DeleteKey(keys ...string) (int, error)
+	Unlink(keys ...string) (int, error)
+	Exists(keys ...string) (int, error)
+	Touch(keys ...string) (int, error)
+	Type(key string) (KeyType, error)
+	Rename(key, newKey string) error
+	RenameIfNotExists(key, newKey string) (bool, error)
+	Copy(src, dst string, replace bool) (bool, error)
 	GetString(key string) (string, error)
 	GetInt64(key string) (int64, error)
+	GetBytes(key string) ([]byte, error)
+	GetFloat64(key string) (float64, error)
+	GetBool(key string) (bool, error)
+	GetTime(key string) (time.Time, error)
+	Get(key string, dst interface{}) error
+	MGetStrings(keys ...string) (map[string]string, error)
 	Set(key string, value interface{}) error
+	MSet(values map[string]interface{}) error
+	SetIfNotExists(key string, value interface{}) (bool, error)
+	SetIfExists(key string, value interface{}) (bool, error)
+	GetAndSet(key string, value interface{}) (string, bool, error)
+	CompareAndSwap(key string, old, new interface{}) (bool, error)
 	SetHash(key string, hash string, value interface{}) error
 	DeleteHash(key string, hash string) error
 	GetHashString(key string, hash string) (string, error)
+	GetHashInt64(key string, hash string) (int64, error)
+	GetHashFloat64(key string, hash string) (float64, error)
+	GetHashBool(key string, hash string) (bool, error)
+	HashExists(key string, hash string) (bool, error)
+	HashLength(key string) (int, error)
+	HashSetIfNotExists(key string, hash string, value interface{}) (bool, error)
+	HashDelete(key string, hashes ...string) (int, error)
 	GetAllHashValues(key string) ([]string, error)
 	GetAllHashKeys(key string) ([]string, error)
+	SetHashStruct(key string, v interface{}) error
+	GetHashStruct(key string, dst interface{}) error
+	HMSet(key string, values map[string]interface{}) error
+	HMGet(key string, hashes ...string) (map[string]string, error)
+	HGetAll(key string) (map[string]string, error)
 	SetExpiry(key string, seconds int) error
+	Expire(key string, ttl time.Duration) error
+	ExpireAt(key string, t time.Time) error
+	Persist(key string) error
+	TTL(key string) (time.Duration, error)
+	SetWithTTL(key string, value interface{}, ttl time.Duration) error
 	Increment(key string) error
 	Decrement(key string) error
+	IncrementBy(key string, n int64) (int64, error)
+	DecrementBy(key string, n int64) (int64, error)
+	IncrementByFloat(key string, n float64) (float64, error)
+	HashIncrementBy(key string, hash string, n int64) (int64, error)
 	SetAdd(key string, value interface{}) error
 	GetSetStringMembers(key string) ([]string, error)
 	SetRemove(key string, value interface{}) error
 	SetIsMember(key string, value interface{}) (bool, error)
+	SetUnion(keys ...string) ([]string, error)
+	SetIntersect(keys ...string) ([]string, error)
+	SetDiff(keys ...string) ([]string, error)
+	SetUnionStore(dst string, keys ...string) (int, error)
+	SetIntersectStore(dst string, keys ...string) (int, error)
+	SetDiffStore(dst string, keys ...string) (int, error)
+	SetCardinality(key string) (int, error)
+	SetPop(key string, count int) ([]string, error)
+	SetRandomMembers(key string, count int) ([]string, error)
+	ZAdd(key string, opts ZAddOptions, members ...ZMember) (int, error)
+	ZIncrBy(key string, member string, n float64) (float64, error)
+	ZScore(key string, member string) (float64, error)
+	ZRank(key string, member string) (int, error)
+	ZRevRank(key string, member string) (int, error)
+	ZRangeByScore(key string, by ZRangeBy) ([]ZMember, error)
+	ZRangeByLex(key string, by ZRangeBy) ([]string, error)
+	ZRem(key string, members ...string) (int, error)
+	ZRemRangeByScore(key string, min, max string) (int, error)
+	ZCard(key string) (int, error)
 	PushItemToList(key string, value interface{}, atEnd bool) error
 	PopItemFromList(key string, dataType int, atEnd bool) (interface{}, error)
 	ItemsFromList(key string, dataType int, start, end int) (interface{}, error)
 	RemoveItemFromList(key string, count int, value interface{}) error
 	LengthOfList(key string) (int, error)
-	ClearDataStore()
+	ItemFromList(key string, dataType int, index int) (interface{}, error)
+	SetItemInList(key string, index int, value interface{}) error
+	TrimList(key string, start, end int) error
+	InsertItemInList(key string, pivot, value interface{}, before bool) (int, error)
+	MoveItemToList(src, dst string, dataType int) (interface{}, error)
+	BlockingPop(keys []string, timeout time.Duration, atEnd bool) (string, string, error)
+	BlockingMove(src, dst string, timeout time.Duration) (string, error)
+	Transaction(watchKeys []string, fn func(tx Tx) error) error
+	Scan(pattern string, count int) *ScanIterator
+	HScan(key string, pattern string, count int) *ScanIterator
+	SScan(key string, pattern string, count int) *ScanIterator
+	DeleteByPattern(pattern string) (int, error)
+	ClearDataStore() error
+}
+
+//ContextStore mirrors Store with a context as the first argument of every method. Implementations abort the operation
+//and return the context error once the context is done.
+type ContextStore interface {
+	DeleteKeyContext(ctx context.Context, keys ...string) (int, error)
+	UnlinkContext(ctx context.Context, keys ...string) (int, error)
+	ExistsContext(ctx context.Context, keys ...string) (int, error)
+	TouchContext(ctx context.Context, keys ...string) (int, error)
+	TypeContext(ctx context.Context, key string) (KeyType, error)
+	RenameContext(ctx context.Context, key, newKey string) error
+	RenameIfNotExistsContext(ctx context.Context, key, newKey string) (bool, error)
+	CopyContext(ctx context.Context, src, dst string, replace bool) (bool, error)
+	GetStringContext(ctx context.Context, key string) (string, error)
+	GetInt64Context(ctx context.Context, key string) (int64, error)
+	GetBytesContext(ctx context.Context, key string) ([]byte, error)
+	GetFloat64Context(ctx context.Context, key string) (float64, error)
+	GetBoolContext(ctx context.Context, key string) (bool, error)
+	GetTimeContext(ctx context.Context, key string) (time.Time, error)
+	GetContext(ctx context.Context, key string, dst interface{}) error
+	MGetStringsContext(ctx context.Context, keys ...string) (map[string]string, error)
+	SetContext(ctx context.Context, key string, value interface{}) error
+	MSetContext(ctx context.Context, values map[string]interface{}) error
+	SetIfNotExistsContext(ctx context.Context, key string, value interface{}) (bool, error)
+	SetIfExistsContext(ctx context.Context, key string, value interface{}) (bool, error)
+	GetAndSetContext(ctx context.Context, key string, value interface{}) (string, bool, error)
+	CompareAndSwapContext(ctx context.Context, key string, old, new interface{}) (bool, error)
+	SetHashContext(ctx context.Context, key string, hash string, value interface{}) error
+	DeleteHashContext(ctx context.Context, key string, hash string) error
+	GetHashStringContext(ctx context.Context, key string, hash string) (string, error)
+	GetHashInt64Context(ctx context.Context, key string, hash string) (int64, error)
+	GetHashFloat64Context(ctx context.Context, key string, hash string) (float64, error)
+	GetHashBoolContext(ctx context.Context, key string, hash string) (bool, error)
+	HashExistsContext(ctx context.Context, key string, hash string) (bool, error)
+	HashLengthContext(ctx context.Context, key string) (int, error)
+	HashSetIfNotExistsContext(ctx context.Context, key string, hash string, value interface{}) (bool, error)
+	HashDeleteContext(ctx context.Context, key string, hashes ...string) (int, error)
+	GetAllHashValuesContext(ctx context.Context, key string) ([]string, error)
+	GetAllHashKeysContext(ctx context.Context, key string) ([]string, error)
+	SetHashStructContext(ctx context.Context, key string, v interface{}) error
+	GetHashStructContext(ctx context.Context, key string, dst interface{}) error
+	HMSetContext(ctx context.Context, key string, values map[string]interface{}) error
+	HMGetContext(ctx context.Context, key string, hashes ...string) (map[string]string, error)
+	HGetAllContext(ctx context.Context, key string) (map[string]string, error)
+	SetExpiryContext(ctx context.Context, key string, seconds int) error
+	ExpireContext(ctx context.Context, key string, ttl time.Duration) error
+	ExpireAtContext(ctx context.Context, key string, t time.Time) error
+	PersistContext(ctx context.Context, key string) error
+	TTLContext(ctx context.Context, key string) (time.Duration, error)
+	SetWithTTLContext(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	IncrementContext(ctx context.Context, key string) error
+	DecrementContext(ctx context.Context, key string) error
+	IncrementByContext(ctx context.Context, key string, n int64) (int64, error)
+	DecrementByContext(ctx context.Context, key string, n int64) (int64, error)
+	IncrementByFloatContext(ctx context.Context, key string, n float64) (float64, error)
+	HashIncrementByContext(ctx context.Context, key string, hash string, n int64) (int64, error)
+	SetAddContext(ctx context.Context, key string, value interface{}) error
+	GetSetStringMembersContext(ctx context.Context, key string) ([]string, error)
+	SetRemoveContext(ctx context.Context, key string, value interface{}) error
+	SetIsMemberContext(ctx context.Context, key string, value interface{}) (bool, error)
+	SetUnionContext(ctx context.Context, keys ...string) ([]string, error)
+	SetIntersectContext(ctx context.Context, keys ...string) ([]string, error)
+	SetDiffContext(ctx context.Context, keys ...string) ([]string, error)
+	SetUnionStoreContext(ctx context.Context, dst string, keys ...string) (int, error)
+	SetIntersectStoreContext(ctx context.Context, dst string, keys ...string) (int, error)
+	SetDiffStoreContext(ctx context.Context, dst string, keys ...string) (int, error)
+	SetCardinalityContext(ctx context.Context, key string) (int, error)
+	SetPopContext(ctx context.Context, key string, count int) ([]string, error)
+	SetRandomMembersContext(ctx context.Context, key string, count int) ([]string, error)
+	ZAddContext(ctx context.Context, key string, opts ZAddOptions, members ...ZMember) (int, error)
+	ZIncrByContext(ctx context.Context, key string, member string, n float64) (float64, error)
+	ZScoreContext(ctx context.Context, key string, member string) (float64, error)
+	ZRankContext(ctx context.Context, key string, member string) (int, error)
+	ZRevRankContext(ctx context.Context, key string, member string) (int, error)
+	ZRangeByScoreContext(ctx context.Context, key string, by ZRangeBy) ([]ZMember, error)
+	ZRangeByLexContext(ctx context.Context, key string, by ZRangeBy) ([]string, error)
+	ZRemContext(ctx context.Context, key string, members ...string) (int, error)
+	ZRemRangeByScoreContext(ctx context.Context, key string, min, max string) (int, error)
+	ZCardContext(ctx context.Context, key string) (int, error)
+	PushItemToListContext(ctx context.Context, key string, value interface{}, atEnd bool) error
+	PopItemFromListContext(ctx context.Context, key string, dataType int, atEnd bool) (interface{}, error)
+	ItemsFromListContext(ctx context.Context, key string, dataType int, start, end int) (interface{}, error)
+	RemoveItemFromListContext(ctx context.Context, key string, count int, value interface{}) error
+	LengthOfListContext(ctx context.Context, key string) (int, error)
+	ItemFromListContext(ctx context.Context, key string, dataType int, index int) (interface{}, error)
+	SetItemInListContext(ctx context.Context, key string, index int, value interface{}) error
+	TrimListContext(ctx context.Context, key string, start, end int) error
+	InsertItemInListContext(ctx context.Context, key string, pivot, value interface{}, before bool) (int, error)
+	MoveItemToListContext(ctx context.Context, src, dst string, dataType int) (interface{}, error)
+	BlockingPopContext(ctx context.Context, keys []string, timeout time.Duration, atEnd bool) (string, string, error)
+	BlockingMoveContext(ctx context.Context, src, dst string, timeout time.Duration) (string, error)
+	TransactionContext(ctx context.Context, watchKeys []string, fn func(tx Tx) error) error
+	ScanContext(ctx context.Context, pattern string, count int) *ScanIterator
+	HScanContext(ctx context.Context, key string, pattern string, count int) *ScanIterator
+	SScanContext(ctx context.Context, key string, pattern string, count int) *ScanIterator
+	DeleteByPatternContext(ctx context.Context, pattern string) (int, error)
+	ClearDataStoreContext(ctx context.Context) error
 }
@@ -0,0 +1,1838 @@
+//Package storetest provides a conformance test suite for implementations of store.Store.
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/awkhan/go-store/store"
+	"github.com/stretchr/testify/assert"
+)
+
+//conformanceTest is a single named behavior checked against a store.
+type conformanceTest struct {
+	name string
+	fn   func(t *testing.T, s store.Store)
+}
+
+var conformanceTests = []conformanceTest{
+	{"Expiry", testExpiry},
+	{"TTL", testTTL},
+	{"GetSetDelString", testGetSetDelString},
+	{"GetSetDelInt", testGetSetDelInt},
+	{"TypedGetters", testTypedGetters},
+	{"Get", testGet},
+	{"IncrDecr", testIncrDecr},
+	{"IncrNonNumeric", testIncrNonNumeric},
+	{"IncrBy", testIncrBy},
+	{"Hash", testHash},
+	{"HashStruct", testHashStruct},
+	{"HashFields", testHashFields},
+	{"HashTypedGetters", testHashTypedGetters},
+	{"MGetMSet", testMGetMSet},
+	{"ConditionalSet", testConditionalSet},
+	{"CompareAndSwap", testCompareAndSwap},
+	{"HMGetHMSet", testHMGetHMSet},
+	{"Pipeline", testPipeline},
+	{"Transaction", testTransaction},
+	{"Scan", testScan},
+	{"ListString", testListString},
+	{"ListRange", testListRange},
+	{"ListRemoveValue", testListRemoveValue},
+	{"ListInt", testListInt},
+	{"ListTypes", testListTypes},
+	{"PopString", testPopString},
+	{"PopBool", testPopBool},
+	{"PopInt", testPopInt},
+	{"PopInt64", testPopInt64},
+	{"PopEmpty", testPopEmpty},
+	{"ListInvalidType", testListInvalidType},
+	{"PopInvalidType", testPopInvalidType},
+	{"ListIndex", testListIndex},
+	{"ListTrim", testListTrim},
+	{"ListInsert", testListInsert},
+	{"ListMove", testListMove},
+	{"BlockingPop", testBlockingPop},
+	{"Set", testSet},
+	{"SetAlgebra", testSetAlgebra},
+	{"SetRandom", testSetRandom},
+	{"SortedSet", testSortedSet},
+	{"SortedSetAddOptions", testSortedSetAddOptions},
+	{"SortedSetRange", testSortedSetRange},
+	{"Keys", testKeys},
+	{"KeyType", testKeyType},
+	{"Copy", testCopy},
+	{"ClearDataStore", testClearDataStore},
+	{"DeleteByPattern", testDeleteByPattern},
+	{"ErrNotFound", testErrNotFound},
+	{"ErrWrongType", testErrWrongType},
+}
+
+//RunConformance runs the conformance suite against the stores returned by factory. The factory is called once per
+//behavior and the store is cleared before use, so it may return either a fresh store or a shared one. Each behavior
+//runs as a subtest so individual failures can be run in isolation with -run.
+func RunConformance(t *testing.T, factory func() store.Store) {
+	for _, ct := range conformanceTests {
+		ct := ct
+		t.Run(ct.name, func(t *testing.T) {
+			s := factory()
+			if err := s.ClearDataStore(); err != nil {
+				t.Fatalf("Unable to clear the store %v", err)
+			}
+			ct.fn(t, s)
+		})
+	}
+}
+
+//assertSameStrings asserts that both slices hold the same strings regardless of order.
+func assertSameStrings(t *testing.T, expected, actual []string, msgAndArgs ...interface{}) {
+	e := append([]string{}, expected...)
+	a := append([]string{}, actual...)
+	sort.Strings(e)
+	sort.Strings(a)
+	assert.Equal(t, e, a, msgAndArgs...)
+}
+
+func testExpiry(t *testing.T, s store.Store) {
+	assert.Nil(t, s.Set("key", "value"), "Error setting value")
+	assert.Nil(t, s.SetExpiry("key", 1), "Error setting expiry")
+
+	v, err := s.GetString("key")
+	assert.Nil(t, err, "Key should exist before it expires")
+	assert.Equal(t, "value", v, "Invalid fetched value")
+
+	time.Sleep(2 * time.Second)
+
+	_, err = s.GetString("key")
+	assert.NotNil(t, err, "Error not found for expired key")
+}
+
+func testTTL(t *testing.T, s store.Store) {
+	_, err := s.TTL("key")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing key should not be found")
+
+	assert.Nil(t, s.Set("key", "value"), "Error setting value")
+	ttl, err := s.TTL("key")
+	assert.Nil(t, err, "Error fetching ttl %v", err)
+	assert.Equal(t, store.NoExpiry, ttl, "Key without expiry should have no ttl")
+
+	assert.Nil(t, s.Expire("key", 1500*time.Millisecond), "Error setting expiry")
+	ttl, err = s.TTL("key")
+	assert.Nil(t, err, "Error fetching ttl %v", err)
+	assert.True(t, ttl > time.Second && ttl <= 1500*time.Millisecond, "Invalid ttl %v", ttl)
+
+	assert.Nil(t, s.Persist("key"), "Error removing expiry")
+	ttl, _ = s.TTL("key")
+	assert.Equal(t, store.NoExpiry, ttl, "Persisted key should have no ttl")
+
+	assert.Nil(t, s.ExpireAt("key", time.Now().Add(2*time.Second)), "Error setting expiry time")
+	ttl, _ = s.TTL("key")
+	assert.True(t, ttl > time.Second && ttl <= 2*time.Second, "Invalid ttl %v", ttl)
+
+	assert.Nil(t, s.Set("key", "other"), "Error setting value")
+	ttl, _ = s.TTL("key")
+	assert.Equal(t, store.NoExpiry, ttl, "Setting a value should remove the expiry")
+
+	assert.Nil(t, s.ExpireAt("key", time.Now().Add(-time.Second)), "Error setting expiry time in the past")
+	_, err = s.GetString("key")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Expiry time in the past should delete the key")
+
+	assert.Nil(t, s.SetWithTTL("temp", "value", 200*time.Millisecond), "Error setting value with ttl")
+	v, err := s.GetString("temp")
+	assert.Nil(t, err, "Error fetching value with ttl %v", err)
+	assert.Equal(t, "value", v, "Invalid fetched value")
+	ttl, _ = s.TTL("temp")
+	assert.True(t, ttl > 0 && ttl <= 200*time.Millisecond, "Invalid ttl %v", ttl)
+
+	time.Sleep(300 * time.Millisecond)
+	_, err = s.GetString("temp")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Value with ttl should expire")
+
+	assert.True(t, errors.Is(s.SetWithTTL("temp", "value", 0), store.ErrInvalidTTL), "Zero ttl should be refused")
+	_, err = s.GetString("temp")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Refused value should not be set")
+
+	assert.Nil(t, s.Set("key", "value"), "Error setting value")
+	assert.Nil(t, s.Expire("key", 0), "Error setting zero expiry")
+	_, err = s.GetString("key")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Zero expiry should delete the key")
+
+	err = s.Transaction(nil, func(tx store.Tx) error {
+		assert.True(t, errors.Is(tx.SetWithTTL("session", "id", 0), store.ErrInvalidTTL), "Zero ttl should be refused")
+		tx.SetWithTTL("session", "id", time.Minute)
+		tx.Set("token", "abc")
+		return tx.Expire("token", time.Minute)
+	})
+	assert.Nil(t, err, "Error setting expiries in a transaction %v", err)
+	ttl, _ = s.TTL("session")
+	assert.True(t, ttl > 59*time.Second && ttl <= time.Minute, "Invalid ttl %v", ttl)
+	ttl, _ = s.TTL("token")
+	assert.True(t, ttl > 59*time.Second && ttl <= time.Minute, "Invalid ttl %v", ttl)
+
+	err = s.Transaction(nil, func(tx store.Tx) error {
+		tx.Persist("session")
+		return tx.ExpireAt("token", time.Now().Add(-time.Second))
+	})
+	assert.Nil(t, err, "Error changing expiries in a transaction %v", err)
+	ttl, _ = s.TTL("session")
+	assert.Equal(t, store.NoExpiry, ttl, "Persisted key should have no ttl")
+	_, err = s.GetString("token")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Expiry time in the past should delete the key")
+}
+
+func testGetSetDelString(t *testing.T, s store.Store) {
+	_, err := s.GetString("invalid_key")
+	assert.NotNil(t, err, "Error should not be empty fetching invalid key")
+
+	assert.Nil(t, s.Set("key", "val"))
+
+	v, err := s.GetString("key")
+	assert.Nil(t, err, "Error fetching string %v", err)
+	assert.Equal(t, "val", v, "Invalid fetched value")
+
+	n, err := s.DeleteKey("key", "missing")
+	assert.Nil(t, err, "Error deleting key %v", err)
+	assert.Equal(t, 1, n, "Only existing keys should be counted as deleted")
+
+	_, err = s.GetString("key")
+	assert.NotNil(t, err, "Error should not be empty fetching deleted key")
+}
+
+func testGetSetDelInt(t *testing.T, s store.Store) {
+	_, err := s.GetInt64("invalid_key")
+	assert.NotNil(t, err, "Error should not be empty fetching invalid key")
+
+	assert.Nil(t, s.Set("key", int64(123)))
+
+	v, err := s.GetInt64("key")
+	assert.Nil(t, err, "Error fetching int64 %v", err)
+	assert.Equal(t, int64(123), v, "Invalid fetched value")
+
+	_, err = s.DeleteKey("key")
+	assert.Nil(t, err, "Error deleting key %v", err)
+}
+
+func testTypedGetters(t *testing.T, s store.Store) {
+	s.Set("float", 2.5)
+	s.Set("bool", true)
+	s.Set("bytes", []byte("abc"))
+	s.Set("rfc3339", "2021-03-04T05:06:07.5Z")
+	s.Set("unix", int64(1614834367))
+
+	f, err := s.GetFloat64("float")
+	assert.Nil(t, err, "Error fetching float64 %v", err)
+	assert.Equal(t, 2.5, f, "Invalid fetched float64")
+
+	b, err := s.GetBool("bool")
+	assert.Nil(t, err, "Error fetching bool %v", err)
+	assert.True(t, b, "Invalid fetched bool")
+
+	data, err := s.GetBytes("bytes")
+	assert.Nil(t, err, "Error fetching bytes %v", err)
+	assert.Equal(t, []byte("abc"), data, "Invalid fetched bytes")
+
+	tm, err := s.GetTime("rfc3339")
+	assert.Nil(t, err, "Error fetching RFC3339 time %v", err)
+	assert.True(t, tm.Equal(time.Date(2021, 3, 4, 5, 6, 7, 5e8, time.UTC)), "Invalid fetched time %v", tm)
+	tm, err = s.GetTime("unix")
+	assert.Nil(t, err, "Error fetching unix time %v", err)
+	assert.Equal(t, int64(1614834367), tm.Unix(), "Invalid fetched unix time")
+
+	stored := time.Date(2021, 3, 4, 5, 6, 7, 123456789, time.FixedZone("", 3600))
+	assert.Nil(t, s.Set("time", stored), "Error setting time")
+	tm, err = s.GetTime("time")
+	assert.Nil(t, err, "Error fetching stored time %v", err)
+	assert.True(t, tm.Equal(stored), "Stored time should round trip, got %v", tm)
+
+	assert.Nil(t, s.PushItemToList("time:list", stored, true), "Error pushing time")
+	items, err := s.ItemsFromList("time:list", store.DataTypeTime, 0, -1)
+	assert.Nil(t, err, "Error retrieving times from list %v", err)
+	if times, ok := items.([]time.Time); assert.True(t, ok && len(times) == 1, "Invalid fetched times %v", items) {
+		assert.True(t, times[0].Equal(stored), "Pushed time should round trip, got %v", times[0])
+	}
+
+	assert.Nil(t, s.SetHash("time:hash", "created", stored), "Error setting hash time")
+	var h struct {
+		Created time.Time `store:"created"`
+	}
+	assert.Nil(t, s.GetHashStruct("time:hash", &h), "Error fetching hash struct")
+	assert.True(t, h.Created.Equal(stored), "Hash time should round trip, got %v", h.Created)
+
+	_, err = s.GetFloat64("bytes")
+	assert.NotNil(t, err, "Fetching a non numeric value as a float64 should fail")
+	_, err = s.GetBool("bytes")
+	assert.NotNil(t, err, "Fetching a non boolean value as a bool should fail")
+	_, err = s.GetTime("bytes")
+	assert.NotNil(t, err, "Fetching an invalid time should fail")
+
+	_, err = s.GetBytes("missing")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing bytes should not be found")
+	_, err = s.GetFloat64("missing")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing float64 should not be found")
+	_, err = s.GetBool("missing")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing bool should not be found")
+	_, err = s.GetTime("missing")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing time should not be found")
+}
+
+func testGet(t *testing.T, s store.Store) {
+	s.Set("int", 42)
+	s.Set("string", "abc")
+	s.Set("time", "2021-03-04T05:06:07Z")
+
+	var n int
+	assert.Nil(t, s.Get("int", &n), "Error scanning int")
+	assert.Equal(t, 42, n, "Invalid scanned int")
+
+	var f float64
+	assert.Nil(t, s.Get("int", &f), "Error scanning float64")
+	assert.Equal(t, 42.0, f, "Invalid scanned float64")
+
+	var str string
+	assert.Nil(t, s.Get("string", &str), "Error scanning string")
+	assert.Equal(t, "abc", str, "Invalid scanned string")
+
+	var tm time.Time
+	assert.Nil(t, s.Get("time", &tm), "Error scanning time")
+	assert.True(t, tm.Equal(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), "Invalid scanned time %v", tm)
+
+	assert.NotNil(t, s.Get("string", &n), "Scanning a string into an int should fail")
+	assert.NotNil(t, s.Get("int", n), "Scanning into a non pointer should fail")
+	assert.True(t, errors.Is(s.Get("missing", &str), store.ErrNotFound), "Missing key should not be found")
+
+	s.SetAdd("set", "a")
+	assert.True(t, errors.Is(s.Get("set", &str), store.ErrWrongType), "Getting a set should be wrong type")
+}
+
+func testIncrDecr(t *testing.T, s store.Store) {
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, s.Increment("key"), "Error incrementing key")
+	}
+
+	v, err := s.GetInt64("key")
+	assert.Nil(t, err, "Error getting incremented key")
+	assert.Equal(t, int64(3), v, "Increment count is invalid")
+
+	assert.Nil(t, s.Decrement("key"), "Error decrementing key")
+	assert.Nil(t, s.Decrement("key"), "Error decrementing key")
+
+	v, err = s.GetInt64("key")
+	assert.Nil(t, err, "Error getting decremented key")
+	assert.Equal(t, int64(1), v, "Decrement count is invalid")
+
+	assert.Nil(t, s.Decrement("new_key"), "Error decrementing missing key")
+
+	v, err = s.GetInt64("new_key")
+	assert.Nil(t, err, "Error getting decremented key")
+	assert.Equal(t, int64(-1), v, "Decrementing a missing key should start at zero")
+}
+
+func testIncrNonNumeric(t *testing.T, s store.Store) {
+	assert.Nil(t, s.Set("key", "abc"))
+	assert.True(t, errors.Is(s.Increment("key"), store.ErrNotInteger), "Incrementing a non numeric value should fail")
+	assert.True(t, errors.Is(s.Decrement("key"), store.ErrNotInteger), "Decrementing a non numeric value should fail")
+
+	v, err := s.GetString("key")
+	assert.Nil(t, err, "Error fetching string %v", err)
+	assert.Equal(t, "abc", v, "Failed increment should not change the value")
+}
+
+func testIncrBy(t *testing.T, s store.Store) {
+	n, err := s.IncrementBy("key", 50)
+	assert.Nil(t, err, "Error incrementing key %v", err)
+	assert.Equal(t, int64(50), n, "Missing key should start at zero")
+
+	n, err = s.DecrementBy("key", 8)
+	assert.Nil(t, err, "Error decrementing key %v", err)
+	assert.Equal(t, int64(42), n, "Invalid decremented value")
+
+	n, err = s.IncrementBy("key", -2)
+	assert.Nil(t, err, "Error incrementing key by a negative value %v", err)
+	assert.Equal(t, int64(40), n, "Invalid incremented value")
+
+	v, err := s.GetInt64("key")
+	assert.Nil(t, err, "Error fetching value %v", err)
+	assert.Equal(t, int64(40), v, "Returned value should be stored")
+
+	s.Set("max", int64(math.MaxInt64))
+	_, err = s.IncrementBy("max", 1)
+	assert.True(t, errors.Is(err, store.ErrOverflow), "Overflowing increment should fail")
+
+	f, err := s.IncrementByFloat("float", 10.5)
+	assert.Nil(t, err, "Error incrementing float %v", err)
+	assert.Equal(t, 10.5, f, "Missing key should start at zero")
+
+	f, err = s.IncrementByFloat("float", -0.25)
+	assert.Nil(t, err, "Error incrementing float %v", err)
+	assert.Equal(t, 10.25, f, "Invalid incremented float")
+
+	str, _ := s.GetString("float")
+	assert.Equal(t, "10.25", str, "Invalid stored float")
+
+	f, err = s.IncrementByFloat("key", 0.5)
+	assert.Nil(t, err, "Error incrementing integer by float %v", err)
+	assert.Equal(t, 40.5, f, "Integer should be incremented as a float")
+
+	_, err = s.IncrementBy("key", 1)
+	assert.True(t, errors.Is(err, store.ErrNotInteger), "Incrementing a float by an integer should fail")
+
+	s.Set("text", "abc")
+	_, err = s.IncrementByFloat("text", 1)
+	assert.True(t, errors.Is(err, store.ErrNotFloat), "Incrementing text by a float should fail")
+
+	n, err = s.HashIncrementBy("hash", "field", 5)
+	assert.Nil(t, err, "Error incrementing hash key %v", err)
+	assert.Equal(t, int64(5), n, "Missing hash key should start at zero")
+
+	n, err = s.HashIncrementBy("hash", "field", -7)
+	assert.Nil(t, err, "Error incrementing hash key %v", err)
+	assert.Equal(t, int64(-2), n, "Invalid incremented hash value")
+
+	hv, _ := s.GetHashString("hash", "field")
+	assert.Equal(t, "-2", hv, "Invalid stored hash value")
+
+	s.SetHash("hash", "text", "abc")
+	_, err = s.HashIncrementBy("hash", "text", 1)
+	assert.True(t, errors.Is(err, store.ErrNotInteger), "Incrementing a text hash value should fail")
+
+	_, err = s.HashIncrementBy("text", "field", 1)
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Incrementing a hash key of a string should be wrong type")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.IncrementBy("counter", 5)
+		}()
+	}
+	wg.Wait()
+	v, _ = s.GetInt64("counter")
+	assert.Equal(t, int64(50), v, "Concurrent increments should all be applied")
+
+	err = s.Transaction([]string{"counter"}, func(tx store.Tx) error {
+		tx.IncrementBy("counter", 10)
+		tx.DecrementBy("counter", 3)
+		tx.IncrementByFloat("float", 0.25)
+		return tx.HashIncrementBy("hash", "field", 4)
+	})
+	assert.Nil(t, err, "Error incrementing in a transaction %v", err)
+	v, _ = s.GetInt64("counter")
+	assert.Equal(t, int64(57), v, "Invalid value after transaction")
+	str, _ = s.GetString("float")
+	assert.Equal(t, "10.5", str, "Invalid float after transaction")
+	hv, _ = s.GetHashString("hash", "field")
+	assert.Equal(t, "2", hv, "Invalid hash value after transaction")
+}
+
+func testHash(t *testing.T, s store.Store) {
+	assert.Nil(t, s.SetHash("key", "hash.key", "val"), "Error setting hash key")
+
+	v, err := s.GetHashString("key", "hash.key")
+	assert.Nil(t, err, "Error fetching hash value")
+	assert.Equal(t, "val", v, "Incorrect fetched value")
+
+	assert.Nil(t, s.DeleteHash("key", "hash.key"), "Error deleting hash value")
+
+	_, err = s.GetHashString("key", "hash.key")
+	assert.NotNil(t, err, "Error should not be empty fetching deleted hash value")
+
+	hkeys := []string{"a", "b", "c"}
+	hvalues := []string{"11", "22", "33"}
+
+	for idx, k := range hkeys {
+		assert.Nil(t, s.SetHash("new_key", k, hvalues[idx]), "Error setting hash key value")
+	}
+
+	rkeys, err := s.GetAllHashKeys("new_key")
+	assert.Nil(t, err, "Error getting all hash keys")
+	assertSameStrings(t, hkeys, rkeys, "Invalid hash keys")
+
+	rvalues, err := s.GetAllHashValues("new_key")
+	assert.Nil(t, err, "Error getting all hash values")
+	assertSameStrings(t, hvalues, rvalues, "Invalid hash values")
+
+	rkeys, err = s.GetAllHashKeys("missing_key")
+	assert.Nil(t, err, "Error getting hash keys of missing key")
+	assert.Equal(t, 0, len(rkeys), "Missing key should have no hash keys")
+}
+
+//hashStruct is stored with SetHashStruct.
+type hashStruct struct {
+	Name     string    `store:"name"`
+	Count    int64     `store:"count"`
+	Score    float64   `store:"score,omitempty"`
+	Active   bool      `store:"active"`
+	Created  time.Time `store:"created"`
+	Note     *string   `store:"note"`
+	Ignored  string    `store:"-"`
+	Untagged uint8
+}
+
+func testHashFields(t *testing.T, s store.Store) {
+	ok, err := s.HashSetIfNotExists("key", "a", 1)
+	assert.Nil(t, err, "Error setting hash key %v", err)
+	assert.True(t, ok, "Missing hash key should be set")
+
+	ok, err = s.HashSetIfNotExists("key", "a", 2)
+	assert.Nil(t, err, "Error setting hash key %v", err)
+	assert.False(t, ok, "Existing hash key should not be set")
+	v, _ := s.GetHashString("key", "a")
+	assert.Equal(t, "1", v, "Existing hash key should keep its value")
+
+	s.HMSet("key", map[string]interface{}{"b": 2, "c": 3})
+
+	ok, err = s.HashExists("key", "b")
+	assert.Nil(t, err, "Error checking hash key %v", err)
+	assert.True(t, ok, "Hash key should exist")
+	ok, _ = s.HashExists("key", "z")
+	assert.False(t, ok, "Hash key should not exist")
+	ok, _ = s.HashExists("missing", "a")
+	assert.False(t, ok, "Hash key of a missing hash should not exist")
+
+	n, err := s.HashLength("key")
+	assert.Nil(t, err, "Error fetching hash length %v", err)
+	assert.Equal(t, 3, n, "Invalid hash length")
+	n, _ = s.HashLength("missing")
+	assert.Equal(t, 0, n, "Missing hash should have no keys")
+
+	n, err = s.HashDelete("key", "a", "b", "z")
+	assert.Nil(t, err, "Error deleting hash keys %v", err)
+	assert.Equal(t, 2, n, "Invalid number of deleted hash keys")
+	all, _ := s.HGetAll("key")
+	assert.Equal(t, map[string]string{"c": "3"}, all, "Invalid hash after deleting keys")
+
+	s.HashDelete("key", "c")
+	n, _ = s.HashLength("key")
+	assert.Equal(t, 0, n, "Deleting every hash key should delete the hash")
+
+	s.Set("string", "abc")
+	_, err = s.HashExists("string", "a")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Checking a hash key of a string should be wrong type")
+	_, err = s.HashSetIfNotExists("string", "a", 1)
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Setting a hash key of a string should be wrong type")
+	_, err = s.HashDelete("string", "a")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Deleting a hash key of a string should be wrong type")
+
+	s.HMSet("user", map[string]interface{}{"name": "abc", "token": "t"})
+	err = s.Transaction([]string{"user"}, func(tx store.Tx) error {
+		ok, err := tx.HashExists("user", "token")
+		if err != nil || !ok {
+			return err
+		}
+		tx.HashDelete("user", "token")
+		tx.HashSetIfNotExists("user", "name", "other")
+		return tx.HashSetIfNotExists("user", "email", "a@b.c")
+	})
+	assert.Nil(t, err, "Error updating a hash in a transaction %v", err)
+	vals, _ := s.HGetAll("user")
+	assert.Equal(t, map[string]string{"name": "abc", "email": "a@b.c"}, vals, "Invalid hash after transaction")
+}
+
+func testHashTypedGetters(t *testing.T, s store.Store) {
+	s.HMSet("key", map[string]interface{}{"int": int64(-42), "float": 2.5, "bool": true, "text": "abc"})
+
+	i, err := s.GetHashInt64("key", "int")
+	assert.Nil(t, err, "Error fetching int64 %v", err)
+	assert.Equal(t, int64(-42), i, "Invalid int64")
+
+	f, err := s.GetHashFloat64("key", "float")
+	assert.Nil(t, err, "Error fetching float64 %v", err)
+	assert.Equal(t, 2.5, f, "Invalid float64")
+
+	b, err := s.GetHashBool("key", "bool")
+	assert.Nil(t, err, "Error fetching bool %v", err)
+	assert.True(t, b, "Invalid bool")
+
+	_, err = s.GetHashInt64("key", "text")
+	assert.NotNil(t, err, "Text should not be an int64")
+	_, err = s.GetHashFloat64("key", "text")
+	assert.NotNil(t, err, "Text should not be a float64")
+	_, err = s.GetHashBool("key", "text")
+	assert.NotNil(t, err, "Text should not be a bool")
+
+	_, err = s.GetHashInt64("key", "missing")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing hash key should be not found")
+	_, err = s.GetHashBool("missing", "bool")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing hash should be not found")
+}
+
+func testHashStruct(t *testing.T, s store.Store) {
+	note := "note"
+	v := hashStruct{
+		Name:     "name",
+		Count:    42,
+		Active:   true,
+		Created:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Note:     &note,
+		Ignored:  "ignored",
+		Untagged: 7,
+	}
+	assert.Nil(t, s.SetHashStruct("key", &v), "Error setting hash struct")
+
+	name, err := s.GetHashString("key", "name")
+	assert.Nil(t, err, "Error fetching hash field %v", err)
+	assert.Equal(t, "name", name, "Field should be stored under its tag name")
+
+	_, err = s.GetHashString("key", "score")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Empty omitempty field should not be stored")
+
+	_, err = s.GetHashString("key", "Ignored")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Ignored field should not be stored")
+
+	var r hashStruct
+	err = s.GetHashStruct("key", &r)
+	assert.Nil(t, err, "Error fetching hash struct %v", err)
+	v.Ignored = ""
+	assert.Equal(t, v, r, "Invalid fetched hash struct")
+
+	assert.Nil(t, s.SetHash("key", "score", "1.5"), "Error setting hash field")
+	assert.Nil(t, s.GetHashStruct("key", &r), "Error fetching hash struct")
+	assert.Equal(t, 1.5, r.Score, "Struct should pick up hash fields set individually")
+
+	err = s.GetHashStruct("missing", &r)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing hash should not be found, got %v", err)
+
+	assert.True(t, errors.Is(s.GetHashStruct("key", r), store.ErrNotStructPointer), "Destination should be a pointer to a struct")
+	assert.True(t, errors.Is(s.SetHashStruct("key", "value"), store.ErrNotStruct), "Value should be a struct")
+
+	assert.Nil(t, s.SetHash("key", "count", "abc"), "Error setting hash field")
+	assert.NotNil(t, s.GetHashStruct("key", &r), "Non numeric field should fail to scan")
+}
+
+func testMGetMSet(t *testing.T, s store.Store) {
+	err := s.MSet(map[string]interface{}{"a": "1", "b": 2, "c": int64(3)})
+	assert.Nil(t, err, "Error setting values %v", err)
+	pushAll(t, s, "list", "x")
+
+	vals, err := s.MGetStrings("a", "missing", "b", "list", "c")
+	assert.Nil(t, err, "Error fetching values %v", err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "c": "3"}, vals, "Missing keys should be left out")
+
+	vals, err = s.MGetStrings()
+	assert.Nil(t, err, "Error fetching no values %v", err)
+	assert.Equal(t, 0, len(vals), "No keys should return no values")
+
+	assert.Nil(t, s.MSet(nil), "Setting no values should succeed")
+}
+
+func testConditionalSet(t *testing.T, s store.Store) {
+	ok, err := s.SetIfExists("key", "value")
+	assert.Nil(t, err, "Error setting missing key if it exists %v", err)
+	assert.False(t, ok, "Missing key should not be set")
+
+	ok, err = s.SetIfNotExists("key", "first")
+	assert.Nil(t, err, "Error setting key if it doesn't exist %v", err)
+	assert.True(t, ok, "Missing key should be set")
+
+	ok, err = s.SetIfNotExists("key", "second")
+	assert.Nil(t, err, "Error setting existing key if it doesn't exist %v", err)
+	assert.False(t, ok, "Existing key should not be overwritten")
+
+	ok, err = s.SetIfExists("key", "third")
+	assert.Nil(t, err, "Error setting existing key if it exists %v", err)
+	assert.True(t, ok, "Existing key should be set")
+
+	old, existed, err := s.GetAndSet("key", "fourth")
+	assert.Nil(t, err, "Error getting and setting key %v", err)
+	assert.True(t, existed, "Existing key should be reported")
+	assert.Equal(t, "third", old, "Invalid replaced value")
+	v, _ := s.GetString("key")
+	assert.Equal(t, "fourth", v, "Invalid value after get and set")
+
+	old, existed, err = s.GetAndSet("missing", 1)
+	assert.Nil(t, err, "Error getting and setting missing key %v", err)
+	assert.False(t, existed, "Missing key should be reported")
+	assert.Equal(t, "", old, "Missing key should replace an empty string")
+	v, _ = s.GetString("missing")
+	assert.Equal(t, "1", v, "Missing key should still be set")
+
+	s.Set("empty", "")
+	old, existed, err = s.GetAndSet("empty", "value")
+	assert.Nil(t, err, "Error getting and setting empty key %v", err)
+	assert.True(t, existed, "Key holding an empty string should exist")
+	assert.Equal(t, "", old, "Invalid replaced value")
+
+	s.SetAdd("set", "a")
+	_, _, err = s.GetAndSet("set", "value")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Getting and setting a set should be wrong type")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if ok, _ := s.SetIfNotExists("lock", i); ok {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, winners, "Only the first writer should win")
+}
+
+func testCompareAndSwap(t *testing.T, s store.Store) {
+	ok, err := s.CompareAndSwap("key", "a", "b")
+	assert.Nil(t, err, "Error swapping missing key %v", err)
+	assert.False(t, ok, "Missing key should not be swapped")
+
+	s.Set("key", "a")
+	ok, err = s.CompareAndSwap("key", "x", "b")
+	assert.Nil(t, err, "Error swapping key %v", err)
+	assert.False(t, ok, "Key holding a different value should not be swapped")
+
+	ok, err = s.CompareAndSwap("key", "a", "b")
+	assert.Nil(t, err, "Error swapping key %v", err)
+	assert.True(t, ok, "Key holding the old value should be swapped")
+	v, _ := s.GetString("key")
+	assert.Equal(t, "b", v, "Invalid value after swap")
+
+	s.SetHash("hash", "field", "a")
+	_, err = s.CompareAndSwap("hash", "a", "b")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Swapping a hash should be wrong type")
+
+	s.Set("counter", 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				n, err := s.GetInt64("counter")
+				if err != nil {
+					return
+				}
+				if ok, err := s.CompareAndSwap("counter", n, n+1); ok || err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	n, _ := s.GetInt64("counter")
+	assert.Equal(t, int64(10), n, "Every swap should be applied exactly once")
+}
+
+func testHMGetHMSet(t *testing.T, s store.Store) {
+	err := s.HMSet("key", map[string]interface{}{"a": "1", "b": 2})
+	assert.Nil(t, err, "Error setting hash values %v", err)
+
+	vals, err := s.HMGet("key", "a", "missing", "b")
+	assert.Nil(t, err, "Error fetching hash values %v", err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, vals, "Missing hash keys should be left out")
+
+	vals, err = s.HMGet("missing", "a")
+	assert.Nil(t, err, "Error fetching hash values of missing key %v", err)
+	assert.Equal(t, 0, len(vals), "Missing key should have no hash values")
+
+	vals, err = s.HGetAll("key")
+	assert.Nil(t, err, "Error fetching all hash values %v", err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, vals, "Invalid hash values")
+
+	vals, err = s.HGetAll("missing")
+	assert.Nil(t, err, "Error fetching all hash values of missing key %v", err)
+	assert.Equal(t, 0, len(vals), "Missing key should have no hash values")
+
+	pushAll(t, s, "list", "x")
+	_, err = s.HMGet("list", "a")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Fetching hash values of a list should be wrong type")
+	_, err = s.HGetAll("list")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Fetching all hash values of a list should be wrong type")
+	assert.True(t, errors.Is(s.HMSet("list", map[string]interface{}{"a": 1}), store.ErrWrongType), "Setting hash values on a list should be wrong type")
+}
+
+func testPipeline(t *testing.T, s store.Store) {
+	p := store.NewPipeline(s)
+
+	results, err := p.Exec()
+	assert.Nil(t, err, "Error running empty pipeline %v", err)
+	assert.Equal(t, 0, len(results), "Empty pipeline should have no results")
+
+	p.Set("key", "value")
+	p.Set("text", "abc")
+	p.Increment("text")
+	p.Increment("counter")
+	p.SetHash("hash", "field", 1)
+	p.PushItemToList("list", "a", true)
+	p.PushItemToList("list", "b", true)
+	p.SetAdd("set", "a")
+	p.SetExpiry("key", 100)
+	p.GetString("key")
+	p.GetString("missing")
+	p.GetInt64("counter")
+	p.GetHashString("hash", "field")
+	p.SetIsMember("set", "a")
+	p.LengthOfList("list")
+	assert.Equal(t, 15, p.Len(), "Invalid number of queued commands")
+
+	results, err = p.Exec()
+	assert.Nil(t, err, "Error running pipeline %v", err)
+	assert.Equal(t, 15, len(results), "Invalid number of results")
+	assert.Equal(t, 0, p.Len(), "Queue should be empty after Exec")
+	if len(results) != 15 {
+		return
+	}
+
+	for _, i := range []int{0, 1, 3, 4, 5, 6, 7, 8} {
+		assert.Nil(t, results[i].Err, "Error running command %d %v", i, results[i].Err)
+		assert.Nil(t, results[i].Value, "Command %d should have no value", i)
+	}
+	assert.True(t, errors.Is(results[2].Err, store.ErrNotInteger), "Incrementing a non numeric value should fail on its own")
+	assert.Equal(t, "value", results[9].Value, "Invalid fetched string")
+	assert.True(t, errors.Is(results[10].Err, store.ErrNotFound), "Missing key should not be found")
+	assert.Equal(t, int64(1), results[11].Value, "Invalid fetched int64")
+	assert.Equal(t, "1", results[12].Value, "Invalid fetched hash value")
+	assert.Equal(t, true, results[13].Value, "Value should be a member of the set")
+	assert.Equal(t, 2, results[14].Value, "Invalid list length")
+
+	var opErr *store.OpError
+	assert.True(t, errors.As(results[2].Err, &opErr), "Command errors should be OpErrors")
+	if opErr != nil {
+		assert.Equal(t, "Increment", opErr.Op, "Invalid operation")
+		assert.Equal(t, "text", opErr.Key, "Invalid key")
+	}
+
+	p.DeleteKey("key")
+	p.DeleteHash("hash", "field")
+	p.SetRemove("set", "a")
+	p.RemoveItemFromList("list", 0, "a")
+	p.Decrement("counter")
+	_, err = p.Exec()
+	assert.Nil(t, err, "Error running pipeline %v", err)
+
+	_, err = s.GetString("key")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Key should be deleted by the pipeline")
+	v, err := s.GetInt64("counter")
+	assert.Nil(t, err, "Error fetching counter %v", err)
+	assert.Equal(t, int64(0), v, "Counter should be decremented by the pipeline")
+	l, err := s.LengthOfList("list")
+	assert.Nil(t, err, "Error fetching list length %v", err)
+	assert.Equal(t, 1, l, "Item should be removed by the pipeline")
+}
+
+func pushAll(t *testing.T, s store.Store, key string, items ...interface{}) {
+	for _, i := range items {
+		assert.Nil(t, s.PushItemToList(key, i, true), "Error pushing item to list")
+	}
+}
+
+func testTransaction(t *testing.T, s store.Store) {
+	s.Set("counter", 1)
+
+	err := s.Transaction([]string{"counter"}, func(tx store.Tx) error {
+		n, err := tx.GetInt64("counter")
+		if err != nil {
+			return err
+		}
+		tx.Set("counter", n+10)
+		tx.SetHash("hash", "field", n)
+		tx.PushItemToList("list", "a", true)
+		return nil
+	})
+	assert.Nil(t, err, "Error running transaction %v", err)
+	n, _ := s.GetInt64("counter")
+	assert.Equal(t, int64(11), n, "Invalid value after transaction")
+	v, _ := s.GetHashString("hash", "field")
+	assert.Equal(t, "1", v, "Invalid hash value after transaction")
+
+	abort := errors.New("abort")
+	err = s.Transaction([]string{"counter"}, func(tx store.Tx) error {
+		tx.Set("counter", 100)
+		return abort
+	})
+	assert.Equal(t, abort, err, "The error of the function should be returned")
+	n, _ = s.GetInt64("counter")
+	assert.Equal(t, int64(11), n, "Aborted transaction should not change the value")
+
+	attempts := 0
+	err = s.Transaction([]string{"counter"}, func(tx store.Tx) error {
+		attempts++
+		if attempts == 1 {
+			s.Increment("counter")
+		}
+		n, err := tx.GetInt64("counter")
+		if err != nil {
+			return err
+		}
+		return tx.Set("counter", n*2)
+	})
+	assert.Nil(t, err, "Error running conflicting transaction %v", err)
+	assert.Equal(t, 2, attempts, "Conflicting transaction should be retried once")
+	n, _ = s.GetInt64("counter")
+	assert.Equal(t, int64(24), n, "Invalid value after retried transaction")
+
+	err = s.Transaction([]string{"counter"}, func(tx store.Tx) error {
+		s.Increment("counter")
+		return tx.Set("counter", 0)
+	})
+	assert.True(t, errors.Is(err, store.ErrTxConflict), "Transaction should fail with a conflict")
+	n, _ = s.GetInt64("counter")
+	assert.NotEqual(t, int64(0), n, "Conflicting transaction should not change the value")
+
+	attempts = 0
+	err = s.Transaction([]string{"counter", "hash"}, func(tx store.Tx) error {
+		attempts++
+		if attempts == 1 {
+			v, _ := s.GetString("counter")
+			s.Set("counter", v)
+			s.SetHash("hash", "temp", 1)
+			s.DeleteHash("hash", "temp")
+		}
+		return tx.Set("flag", attempts)
+	})
+	assert.Nil(t, err, "Error running transaction %v", err)
+	assert.Equal(t, 2, attempts, "Writes leaving the same value should still conflict")
+
+	s.Set("text", "abc")
+	err = s.Transaction(nil, func(tx store.Tx) error {
+		tx.Increment("text")
+		return tx.Set("other", "value")
+	})
+	assert.True(t, errors.Is(err, store.ErrNotInteger), "Failing command should be returned")
+	v, _ = s.GetString("other")
+	assert.Equal(t, "value", v, "Other commands should still be applied")
+
+	s.PushItemToList("queue", "job1", true)
+	s.PushItemToList("queue", "job2", true)
+	err = s.Transaction([]string{"queue"}, func(tx store.Tx) error {
+		items, err := tx.ItemsFromList("queue", store.DataTypeString, 0, 0)
+		if err != nil {
+			return err
+		}
+		job := items.([]string)[0]
+		tx.PopItemFromList("queue", false)
+		tx.PushItemToList("done", job, true)
+		tx.HMSet("stats", map[string]interface{}{"last": job})
+		return tx.Increment("moved")
+	})
+	assert.Nil(t, err, "Error moving an item in a transaction %v", err)
+	items, _ := s.ItemsFromList("queue", store.DataTypeString, 0, -1)
+	assert.Equal(t, []string{"job2"}, items, "The item should be popped from the source list")
+	items, _ = s.ItemsFromList("done", store.DataTypeString, 0, -1)
+	assert.Equal(t, []string{"job1"}, items, "The item should be pushed to the destination list")
+	n, _ = s.GetInt64("moved")
+	assert.Equal(t, int64(1), n, "The counter should be incremented with the move")
+	v, _ = s.GetHashString("stats", "last")
+	assert.Equal(t, "job1", v, "Invalid hash value after transaction")
+
+	err = s.Transaction(nil, func(tx store.Tx) error {
+		tx.PopItemFromList("empty", true)
+		return tx.Set("after", 1)
+	})
+	assert.Nil(t, err, "Popping an empty list in a transaction should do nothing %v", err)
+	v, _ = s.GetString("after")
+	assert.Equal(t, "1", v, "Commands after the pop should be applied")
+}
+
+func testScan(t *testing.T, s store.Store) {
+	for i := 0; i < 25; i++ {
+		s.Set(fmt.Sprintf("user:%d", i), i)
+		s.SetHash("hash", fmt.Sprintf("field%d", i), i)
+		s.SetAdd("set", fmt.Sprintf("member%d", i))
+	}
+	s.Set("other", "value")
+	s.Set("user", "value")
+
+	keys := map[string]bool{}
+	it := s.Scan("user:*", 5)
+	for it.Next() {
+		keys[it.Val()] = true
+	}
+	assert.Nil(t, it.Err(), "Error scanning keys %v", it.Err())
+	assert.Equal(t, 25, len(keys), "Invalid number of scanned keys")
+	assert.True(t, keys["user:7"], "Matching key should be scanned")
+	assert.False(t, keys["user"], "Key not matching the pattern should not be scanned")
+
+	all := map[string]bool{}
+	it = s.Scan("", 0)
+	for it.Next() {
+		all[it.Val()] = true
+	}
+	assert.Equal(t, 29, len(all), "Empty pattern should scan all keys")
+
+	it = s.Scan("", 1)
+	assert.True(t, it.Next(), "Scan should return a key")
+	assert.Nil(t, it.Err(), "Stopping early should not fail %v", it.Err())
+
+	fields := map[string]string{}
+	it = s.HScan("hash", "field1*", 3)
+	for it.Next() {
+		fields[it.Val()] = it.HashValue()
+	}
+	assert.Nil(t, it.Err(), "Error scanning hash %v", it.Err())
+	assert.Equal(t, 11, len(fields), "Invalid number of scanned hash keys")
+	assert.Equal(t, "12", fields["field12"], "Invalid scanned hash value")
+
+	members := map[string]bool{}
+	it = s.SScan("set", "member[0-4]", 2)
+	for it.Next() {
+		members[it.Val()] = true
+	}
+	assert.Nil(t, it.Err(), "Error scanning set %v", it.Err())
+	assert.Equal(t, 5, len(members), "Invalid number of scanned members")
+
+	it = s.SScan("missing", "", 0)
+	assert.False(t, it.Next(), "Missing set should have no members")
+	assert.Nil(t, it.Err(), "Missing set should not fail %v", it.Err())
+
+	it = s.HScan("set", "", 0)
+	assert.False(t, it.Next(), "Scanning a set as a hash should stop")
+	assert.True(t, errors.Is(it.Err(), store.ErrWrongType), "Scanning a set as a hash should be wrong type")
+}
+
+func testListString(t *testing.T, s store.Store) {
+	items := []interface{}{"abcdefg", "ajsdfjalsdfasdf", "asdfasdfasdf", "adsfasdfasfasdfa"}
+	pushAll(t, s, "lkey", items...)
+
+	l, err := s.LengthOfList("lkey")
+	assert.Nil(t, err, "Error retrieving length of list %v", err)
+	assert.Equal(t, len(items), l, "Length of items incorrect")
+
+	assert.Nil(t, s.PushItemToList("lkey", "first", false), "Error pushing item to front of list")
+
+	item, err := s.PopItemFromList("lkey", store.DataTypeString, false)
+	assert.Nil(t, err, "Error popping item from list %v", err)
+	assert.Equal(t, "first", item, "Popped item not equal to item pushed in front")
+
+	item, err = s.PopItemFromList("lkey", store.DataTypeString, true)
+	assert.Nil(t, err, "Error popping item from list %v", err)
+	assert.Equal(t, items[3], item, "Popped item not equal to last item")
+
+	item, err = s.PopItemFromList("lkey", store.DataTypeString, false)
+	assert.Nil(t, err, "Error popping item from list %v", err)
+	assert.Equal(t, items[0], item, "Popped item not equal to first item")
+
+	l, err = s.LengthOfList("lkey")
+	assert.Nil(t, err, "Error retrieving length of list %v", err)
+	assert.Equal(t, 2, l, "Length of items incorrect after popping")
+}
+
+func testListRange(t *testing.T, s store.Store) {
+	items := []interface{}{"abcdefg", "ajsdfjalsdfasdf", "asdfasdfasdf", "adsfasdfasfasdfa"}
+	pushAll(t, s, "lkey", items...)
+
+	fi, err := s.ItemsFromList("lkey", store.DataTypeString, 0, 2)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, []string{"abcdefg", "ajsdfjalsdfasdf", "asdfasdfasdf"}, fi, "Invalid fetched items")
+
+	fi, err = s.ItemsFromList("lkey", store.DataTypeString, -2, -1)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, []string{"asdfasdfasdf", "adsfasdfasfasdfa"}, fi, "Invalid fetched items for negative range")
+
+	fi, err = s.ItemsFromList("lkey", store.DataTypeString, 10, 20)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, 0, len(fi.([]string)), "Out of range should return no items")
+}
+
+func testListRemoveValue(t *testing.T, s store.Store) {
+	pushAll(t, s, "lkey", "abcdefg", "abcdefg", "asdfasdfasdf", "adsfasdfasfasdfa", "abcdefg")
+
+	assert.Nil(t, s.RemoveItemFromList("lkey", 1, "abcdefg"), "Error removing item from list")
+
+	fi, err := s.ItemsFromList("lkey", store.DataTypeString, 0, -1)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, []string{"abcdefg", "asdfasdfasdf", "adsfasdfasfasdfa", "abcdefg"}, fi, "Invalid items after removing from head")
+
+	assert.Nil(t, s.RemoveItemFromList("lkey", -1, "abcdefg"), "Error removing item from list")
+
+	fi, err = s.ItemsFromList("lkey", store.DataTypeString, 0, -1)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, []string{"abcdefg", "asdfasdfasdf", "adsfasdfasfasdfa"}, fi, "Invalid items after removing from tail")
+
+	assert.Nil(t, s.RemoveItemFromList("lkey", 0, "abcdefg"), "Error removing item from list")
+
+	fi, err = s.ItemsFromList("lkey", store.DataTypeString, 0, -1)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, []string{"asdfasdfasdf", "adsfasdfasfasdfa"}, fi, "Invalid items after removing all occurrences")
+}
+
+func testListInt(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", 1, 2, 4)
+
+	fi, err := s.ItemsFromList("key", store.DataTypeInt, 0, 3)
+	assert.Nil(t, err, "Error retrieving items from list %v", err)
+	assert.Equal(t, []int{1, 2, 4}, fi, "Invalid fetched items")
+}
+
+func testListTypes(t *testing.T, s store.Store) {
+	pushAll(t, s, "bools", true, false)
+	items, err := s.ItemsFromList("bools", store.DataTypeBool, 0, -1)
+	assert.Nil(t, err, "Error retrieving bools from list %v", err)
+	assert.Equal(t, []bool{true, false}, items, "Invalid fetched bools")
+
+	pushAll(t, s, "numbers", 1, int64(1)<<40, 2.5)
+	items, err = s.ItemsFromList("numbers", store.DataTypeInt64, 0, 1)
+	assert.Nil(t, err, "Error retrieving int64s from list %v", err)
+	assert.Equal(t, []int64{1, 1 << 40}, items, "Invalid fetched int64s")
+	items, err = s.ItemsFromList("numbers", store.DataTypeFloat64, 0, -1)
+	assert.Nil(t, err, "Error retrieving float64s from list %v", err)
+	assert.Equal(t, []float64{1, 1 << 40, 2.5}, items, "Invalid fetched float64s")
+	_, err = s.ItemsFromList("numbers", store.DataTypeInt64, 0, -1)
+	assert.NotNil(t, err, "Fetching a float as an int64 should fail")
+
+	items, err = s.ItemsFromList("numbers", store.DataTypeBytes, 0, 0)
+	assert.Nil(t, err, "Error retrieving bytes from list %v", err)
+	assert.Equal(t, [][]byte{[]byte("1")}, items, "Invalid fetched bytes")
+
+	pushAll(t, s, "times", "2021-03-04T05:06:07Z", 1614834367)
+	items, err = s.ItemsFromList("times", store.DataTypeTime, 0, -1)
+	assert.Nil(t, err, "Error retrieving times from list %v", err)
+	if times, ok := items.([]time.Time); assert.True(t, ok, "Times should be returned as []time.Time") {
+		assert.Equal(t, 2, len(times), "Invalid number of fetched times")
+		assert.True(t, times[0].Equal(times[1]), "RFC3339 and unix times should match, got %v", times)
+	}
+
+	items, err = s.ItemsFromList("missing", store.DataTypeBool, 0, -1)
+	assert.Nil(t, err, "Error retrieving items from missing list %v", err)
+	assert.Equal(t, []bool{}, items, "Missing list should have no items")
+}
+
+func testPopString(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", "a", "b")
+
+	i, err := s.PopItemFromList("key", store.DataTypeString, true)
+	assert.Nil(t, err, "Error popping item from list %v", err)
+	assert.Equal(t, "b", i, "Last item should be b")
+}
+
+func testPopBool(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", true, false, true)
+
+	for _, expected := range []bool{true, false, true} {
+		i, err := s.PopItemFromList("key", store.DataTypeBool, true)
+		assert.Nil(t, err, "Error popping item from list %v", err)
+		assert.Equal(t, expected, i, "Invalid popped bool")
+	}
+}
+
+func testPopInt(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", 1, 2, 3)
+
+	for _, expected := range []int{3, 2, 1} {
+		i, err := s.PopItemFromList("key", store.DataTypeInt, true)
+		assert.Nil(t, err, "Error popping item from list %v", err)
+		assert.Equal(t, expected, i, "Invalid popped int")
+	}
+}
+
+func testPopInt64(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", int64(1), int64(2), int64(3))
+
+	for _, expected := range []int64{3, 2, 1} {
+		i, err := s.PopItemFromList("key", store.DataTypeInt64, true)
+		assert.Nil(t, err, "Error popping item from list %v", err)
+		assert.Equal(t, expected, i, "Invalid popped int64")
+	}
+}
+
+func testPopEmpty(t *testing.T, s store.Store) {
+	_, err := s.PopItemFromList("key", store.DataTypeString, true)
+	assert.NotNil(t, err, "Popping from an empty list should fail")
+
+	l, err := s.LengthOfList("key")
+	assert.Nil(t, err, "Error retrieving length of list %v", err)
+	assert.Equal(t, 0, l, "Missing list should have no items")
+}
+
+func testListInvalidType(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", 1, 2, 4)
+
+	_, err := s.ItemsFromList("key", 23123123, 0, 3)
+	assert.True(t, errors.Is(err, store.ErrInvalidDataType), "There should have been an error retrieving an invalid data type")
+}
+
+func testPopInvalidType(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", 1)
+
+	_, err := s.PopItemFromList("key", 23123123, true)
+	assert.True(t, errors.Is(err, store.ErrInvalidDataType), "There should have been an error retrieving an invalid data type")
+}
+
+func testListIndex(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", 1, 2, 3)
+
+	v, err := s.ItemFromList("key", store.DataTypeInt, 0)
+	assert.Nil(t, err, "Error fetching item %v", err)
+	assert.Equal(t, 1, v, "Invalid first item")
+
+	v, err = s.ItemFromList("key", store.DataTypeString, -1)
+	assert.Nil(t, err, "Error fetching item %v", err)
+	assert.Equal(t, "3", v, "Negative index should count from the end")
+
+	_, err = s.ItemFromList("key", store.DataTypeString, 3)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Index past the end should be not found")
+
+	_, err = s.ItemFromList("key", 23123123, 0)
+	assert.True(t, errors.Is(err, store.ErrInvalidDataType), "There should have been an error retrieving an invalid data type")
+
+	assert.Nil(t, s.SetItemInList("key", -2, 20), "Error setting item")
+	v, _ = s.ItemFromList("key", store.DataTypeInt64, 1)
+	assert.Equal(t, int64(20), v, "Invalid item after set")
+
+	err = s.SetItemInList("key", 3, 4)
+	assert.True(t, errors.Is(err, store.ErrIndexOutOfRange), "Setting past the end should be out of range")
+
+	err = s.SetItemInList("missing", 0, 4)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Setting an item of a missing list should be not found")
+
+	s.Set("string", "abc")
+	_, err = s.ItemFromList("string", store.DataTypeString, 0)
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Fetching an item of a string should be wrong type")
+}
+
+func testListTrim(t *testing.T, s store.Store) {
+	pushAll(t, s, "events", "a", "b", "c", "d", "e")
+
+	assert.Nil(t, s.TrimList("events", -3, -1), "Error trimming list")
+	items, _ := s.ItemsFromList("events", store.DataTypeString, 0, -1)
+	assert.Equal(t, []string{"c", "d", "e"}, items, "List should keep the last three items")
+
+	assert.Nil(t, s.TrimList("events", 5, 10), "Error trimming list to an empty range")
+	l, _ := s.LengthOfList("events")
+	assert.Equal(t, 0, l, "Empty range should delete the list")
+
+	assert.Nil(t, s.TrimList("missing", 0, 1), "Trimming a missing list should not fail")
+}
+
+func testListInsert(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", "a", "c", "a")
+
+	n, err := s.InsertItemInList("key", "a", "x", false)
+	assert.Nil(t, err, "Error inserting item %v", err)
+	assert.Equal(t, 4, n, "Insert should return the new length")
+
+	n, err = s.InsertItemInList("key", "c", "b", true)
+	assert.Nil(t, err, "Error inserting item %v", err)
+	assert.Equal(t, 5, n, "Insert should return the new length")
+
+	items, _ := s.ItemsFromList("key", store.DataTypeString, 0, -1)
+	assert.Equal(t, []string{"a", "x", "b", "c", "a"}, items, "Items should be inserted next to the first pivot")
+
+	_, err = s.InsertItemInList("key", "z", "y", true)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing pivot should be not found")
+
+	_, err = s.InsertItemInList("missing", "a", "y", true)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing list should be not found")
+	l, _ := s.LengthOfList("missing")
+	assert.Equal(t, 0, l, "Inserting into a missing list should not create it")
+}
+
+func testListMove(t *testing.T, s store.Store) {
+	pushAll(t, s, "queue", 1, 2, 3)
+
+	v, err := s.MoveItemToList("queue", "processing", store.DataTypeInt)
+	assert.Nil(t, err, "Error moving item %v", err)
+	assert.Equal(t, 3, v, "Item should be moved from the end of the source")
+
+	s.MoveItemToList("queue", "processing", store.DataTypeString)
+	items, _ := s.ItemsFromList("processing", store.DataTypeInt, 0, -1)
+	assert.Equal(t, []int{2, 3}, items, "Items should be pushed to the front of the destination")
+
+	v, err = s.MoveItemToList("queue", "queue", store.DataTypeString)
+	assert.Nil(t, err, "Error rotating list %v", err)
+	assert.Equal(t, "1", v, "Invalid rotated item")
+
+	s.MoveItemToList("queue", "processing", store.DataTypeString)
+	_, err = s.MoveItemToList("queue", "processing", store.DataTypeString)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Moving from an empty list should be not found")
+
+	pushAll(t, s, "queue", 4)
+	s.Set("string", "abc")
+	_, err = s.MoveItemToList("queue", "string", store.DataTypeString)
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Moving to a string should be wrong type")
+	l, _ := s.LengthOfList("queue")
+	assert.Equal(t, 1, l, "Failed move should leave the source untouched")
+
+	pushAll(t, s, "jobs", "a", "b", "c")
+	err = s.Transaction([]string{"jobs"}, func(tx store.Tx) error {
+		job, err := tx.ItemFromList("jobs", store.DataTypeString, -1)
+		if err != nil {
+			return err
+		}
+		tx.MoveItemToList("jobs", "working")
+		tx.SetItemInList("working", 0, job.(string)+"!")
+		tx.TrimList("jobs", 0, 0)
+		tx.InsertItemInList("jobs", "a", "z", false)
+		tx.InsertItemInList("jobs", "missing", "z", false)
+		tx.MoveItemToList("empty", "working")
+		return tx.Increment("started")
+	})
+	assert.Nil(t, err, "Error moving an item in a transaction %v", err)
+	items, _ = s.ItemsFromList("jobs", store.DataTypeString, 0, -1)
+	assert.Equal(t, []string{"a", "z"}, items, "Invalid source list after transaction")
+	items, _ = s.ItemsFromList("working", store.DataTypeString, 0, -1)
+	assert.Equal(t, []string{"c!"}, items, "Invalid destination list after transaction")
+	n, _ := s.GetInt64("started")
+	assert.Equal(t, int64(1), n, "The counter should be incremented with the move")
+}
+
+func testBlockingPop(t *testing.T, s store.Store) {
+	pushAll(t, s, "b", "1", "2")
+
+	key, item, err := s.BlockingPop([]string{"a", "b"}, time.Second, false)
+	assert.Nil(t, err, "Error popping from a non empty list %v", err)
+	assert.Equal(t, "b", key, "Item should be popped from the first non empty list")
+	assert.Equal(t, "1", item, "Invalid item popped from the front")
+
+	_, item, err = s.BlockingPop([]string{"a", "b"}, time.Second, true)
+	assert.Nil(t, err, "Error popping from the end %v", err)
+	assert.Equal(t, "2", item, "Invalid item popped from the end")
+
+	start := time.Now()
+	_, _, err = s.BlockingPop([]string{"a", "b"}, 100*time.Millisecond, false)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Popping from empty lists should time out with not found")
+	assert.True(t, time.Since(start) < 500*time.Millisecond, "Sub-second timeout should not be rounded up to a second")
+
+	_, _, err = s.BlockingPop([]string{"a"}, -time.Second, false)
+	assert.True(t, errors.Is(err, store.ErrNegativeTimeout), "Negative timeout should be refused")
+	_, _, err = s.BlockingPop(nil, 0, false)
+	assert.True(t, errors.Is(err, store.ErrNoKeys), "Popping without keys should be refused instead of blocking")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.PushItemToList("a", "pushed", true)
+	}()
+	key, item, err = s.BlockingPop([]string{"a"}, 5*time.Second, false)
+	assert.Nil(t, err, "Push should wake the blocked pop %v", err)
+	assert.Equal(t, "a", key, "Invalid key of the pushed item")
+	assert.Equal(t, "pushed", item, "Invalid pushed item")
+
+	pushAll(t, s, "src", "1", "2")
+	item, err = s.BlockingMove("src", "dst", time.Second)
+	assert.Nil(t, err, "Error moving item %v", err)
+	assert.Equal(t, "2", item, "Item should be moved from the end of the source")
+	dst, _ := s.ItemsFromList("dst", store.DataTypeString, 0, -1)
+	assert.Equal(t, []string{"2"}, dst, "Item should be pushed to the front of the destination")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.PushItemToList("empty", "pushed", true)
+	}()
+	item, err = s.BlockingMove("empty", "dst", 5*time.Second)
+	assert.Nil(t, err, "Push should wake the blocked move %v", err)
+	assert.Equal(t, "pushed", item, "Invalid moved item")
+
+	s.Set("string", "abc")
+	_, _, err = s.BlockingPop([]string{"string"}, time.Second, false)
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Popping from a string should be wrong type")
+}
+
+func testSet(t *testing.T, s store.Store) {
+	sv := []string{"a", "b", "c"}
+
+	for _, v := range sv {
+		assert.Nil(t, s.SetAdd("key", v), "Error adding item to set")
+	}
+	assert.Nil(t, s.SetAdd("key", "a"), "Error adding duplicate item to set")
+
+	b, err := s.SetIsMember("key", "a")
+	assert.Nil(t, err, "Error getting set value")
+	assert.True(t, b, "Value should be a member of set %s", sv)
+
+	b, err = s.SetIsMember("key", "123123123123123")
+	assert.Nil(t, err, "Error getting set value")
+	assert.False(t, b, "Value should not be a member of set %s", sv)
+
+	members, err := s.GetSetStringMembers("key")
+	assert.Nil(t, err, "Error getting set members")
+	assertSameStrings(t, sv, members, "Invalid set members")
+
+	assert.Nil(t, s.SetRemove("key", "a"), "Error removing item from set")
+
+	members, err = s.GetSetStringMembers("key")
+	assert.Nil(t, err, "Error getting set members")
+	assertSameStrings(t, []string{"b", "c"}, members, "Invalid set members after removal")
+}
+
+//addAll adds the members to the set.
+func addAll(t *testing.T, s store.Store, key string, members ...string) {
+	for _, m := range members {
+		assert.Nil(t, s.SetAdd(key, m), "Error adding item to set")
+	}
+}
+
+func testSetAlgebra(t *testing.T, s store.Store) {
+	addAll(t, s, "a", "1", "2", "3")
+	addAll(t, s, "b", "2", "3", "4")
+	addAll(t, s, "c", "3", "5")
+
+	members, err := s.SetUnion("a", "b", "missing")
+	assert.Nil(t, err, "Error computing union %v", err)
+	assertSameStrings(t, []string{"1", "2", "3", "4"}, members, "Invalid union")
+
+	members, err = s.SetIntersect("a", "b", "c")
+	assert.Nil(t, err, "Error computing intersection %v", err)
+	assertSameStrings(t, []string{"3"}, members, "Invalid intersection")
+
+	members, err = s.SetIntersect("a", "missing")
+	assert.Nil(t, err, "Error computing intersection %v", err)
+	assert.Empty(t, members, "Intersection with a missing set should be empty")
+
+	members, err = s.SetDiff("a", "b")
+	assert.Nil(t, err, "Error computing difference %v", err)
+	assertSameStrings(t, []string{"1"}, members, "Invalid difference")
+
+	n, err := s.SetUnionStore("dst", "a", "c")
+	assert.Nil(t, err, "Error storing union %v", err)
+	assert.Equal(t, 4, n, "Invalid number of members stored")
+	members, _ = s.GetSetStringMembers("dst")
+	assertSameStrings(t, []string{"1", "2", "3", "5"}, members, "Invalid stored union")
+
+	n, err = s.SetIntersectStore("dst", "a", "b")
+	assert.Nil(t, err, "Error storing intersection %v", err)
+	assert.Equal(t, 2, n, "Invalid number of members stored")
+	members, _ = s.GetSetStringMembers("dst")
+	assertSameStrings(t, []string{"2", "3"}, members, "Stored intersection should replace the destination")
+
+	s.Set("string", "abc")
+	n, err = s.SetDiffStore("string", "b", "a")
+	assert.Nil(t, err, "Error storing difference %v", err)
+	assert.Equal(t, 1, n, "Invalid number of members stored")
+	members, _ = s.GetSetStringMembers("string")
+	assertSameStrings(t, []string{"4"}, members, "Stored difference should replace a destination of another type")
+
+	n, err = s.SetDiffStore("dst", "a", "a")
+	assert.Nil(t, err, "Error storing empty difference %v", err)
+	assert.Equal(t, 0, n, "Empty difference should store no members")
+	n, _ = s.SetCardinality("dst")
+	assert.Equal(t, 0, n, "Empty result should delete the destination")
+
+	n, err = s.SetCardinality("a")
+	assert.Nil(t, err, "Error fetching cardinality %v", err)
+	assert.Equal(t, 3, n, "Invalid cardinality")
+
+	s.Set("text", "abc")
+	_, err = s.SetUnion("a", "text")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Union with a string should be wrong type")
+	_, err = s.SetCardinality("text")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Cardinality of a string should be wrong type")
+
+	_, err = s.SetUnion()
+	assert.True(t, errors.Is(err, store.ErrNoKeys), "Union of no keys should fail")
+
+	err = s.Transaction([]string{"a", "b"}, func(tx store.Tx) error {
+		assert.True(t, errors.Is(tx.SetUnionStore("all"), store.ErrNoKeys), "Union of no keys should fail")
+		tx.SetUnionStore("all", "a", "b")
+		tx.SetIntersectStore("both", "a", "b")
+		return tx.SetDiffStore("only", "a", "b")
+	})
+	assert.Nil(t, err, "Error storing sets in a transaction %v", err)
+	members, _ = s.GetSetStringMembers("all")
+	assertSameStrings(t, []string{"1", "2", "3", "4"}, members, "Invalid stored union")
+	members, _ = s.GetSetStringMembers("both")
+	assertSameStrings(t, []string{"2", "3"}, members, "Invalid stored intersection")
+	members, _ = s.GetSetStringMembers("only")
+	assertSameStrings(t, []string{"1"}, members, "Invalid stored difference")
+}
+
+func testSetRandom(t *testing.T, s store.Store) {
+	all := []string{"a", "b", "c", "d"}
+	addAll(t, s, "key", all...)
+
+	sample, err := s.SetRandomMembers("key", 2)
+	assert.Nil(t, err, "Error sampling set %v", err)
+	assert.Len(t, sample, 2, "Invalid sample size")
+	assert.NotEqual(t, sample[0], sample[1], "Positive count should return distinct members")
+
+	sample, _ = s.SetRandomMembers("key", 10)
+	assertSameStrings(t, all, sample, "Count larger than the set should return every member")
+
+	sample, _ = s.SetRandomMembers("key", -10)
+	assert.Len(t, sample, 10, "Negative count should return exactly that many members")
+
+	n, _ := s.SetCardinality("key")
+	assert.Equal(t, 4, n, "Sampling should not remove members")
+
+	popped, err := s.SetPop("key", 3)
+	assert.Nil(t, err, "Error popping from set %v", err)
+	assert.Len(t, popped, 3, "Invalid number of popped members")
+
+	rest, _ := s.GetSetStringMembers("key")
+	assertSameStrings(t, all, append(popped, rest...), "Popped members should be removed from the set")
+
+	popped, _ = s.SetPop("key", 5)
+	assert.Len(t, popped, 1, "Pop should return the remaining members")
+	n, _ = s.SetCardinality("key")
+	assert.Equal(t, 0, n, "Popping every member should delete the set")
+
+	popped, err = s.SetPop("missing", 1)
+	assert.Nil(t, err, "Error popping from a missing set %v", err)
+	assert.Empty(t, popped, "Popping from a missing set should return no members")
+
+	_, err = s.SetPop("key", -1)
+	assert.True(t, errors.Is(err, store.ErrNegativeCount), "Negative count should be refused")
+
+	sample, err = s.SetRandomMembers("missing", 1)
+	assert.Nil(t, err, "Error sampling a missing set %v", err)
+	assert.Empty(t, sample, "Sampling a missing set should return no members")
+}
+
+func testSortedSet(t *testing.T, s store.Store) {
+	n, err := s.ZAdd("board", store.ZAddOptions{}, store.ZMember{Member: "a", Score: 10}, store.ZMember{Member: "b", Score: 20}, store.ZMember{Member: "c", Score: 15})
+	assert.Nil(t, err, "Error adding members %v", err)
+	assert.Equal(t, 3, n, "Invalid number of added members")
+
+	n, err = s.ZAdd("board", store.ZAddOptions{}, store.ZMember{Member: "a", Score: 30})
+	assert.Nil(t, err, "Error updating member %v", err)
+	assert.Equal(t, 0, n, "Updated members should not be counted as added")
+
+	score, err := s.ZScore("board", "a")
+	assert.Nil(t, err, "Error fetching score %v", err)
+	assert.Equal(t, 30.0, score, "Invalid updated score")
+
+	score, err = s.ZIncrBy("board", "c", 2.5)
+	assert.Nil(t, err, "Error incrementing score %v", err)
+	assert.Equal(t, 17.5, score, "Invalid incremented score")
+
+	score, err = s.ZIncrBy("board", "d", -1)
+	assert.Nil(t, err, "Error incrementing missing member %v", err)
+	assert.Equal(t, -1.0, score, "Missing member should start at zero")
+
+	s.ZAdd("inf", store.ZAddOptions{}, store.ZMember{Member: "a", Score: math.Inf(1)})
+	_, err = s.ZIncrBy("inf", "a", math.Inf(-1))
+	assert.True(t, errors.Is(err, store.ErrScoreNaN), "Increment resulting in NaN should fail")
+
+	rank, err := s.ZRank("board", "c")
+	assert.Nil(t, err, "Error fetching rank %v", err)
+	assert.Equal(t, 1, rank, "Invalid rank")
+
+	rank, err = s.ZRevRank("board", "a")
+	assert.Nil(t, err, "Error fetching reverse rank %v", err)
+	assert.Equal(t, 0, rank, "Highest score should have reverse rank zero")
+
+	_, err = s.ZScore("board", "missing")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Score of a missing member should be not found")
+	_, err = s.ZRank("board", "missing")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Rank of a missing member should be not found")
+	_, err = s.ZRevRank("missing", "a")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Rank in a missing set should be not found")
+
+	n, err = s.ZCard("board")
+	assert.Nil(t, err, "Error fetching cardinality %v", err)
+	assert.Equal(t, 4, n, "Invalid cardinality")
+
+	n, err = s.ZRem("board", "d", "missing")
+	assert.Nil(t, err, "Error removing members %v", err)
+	assert.Equal(t, 1, n, "Invalid number of removed members")
+
+	n, err = s.ZRemRangeByScore("board", "(17.5", "+inf")
+	assert.Nil(t, err, "Error removing range %v", err)
+	assert.Equal(t, 2, n, "Invalid number of members removed by score")
+	members, _ := s.ZRangeByScore("board", store.ZRangeBy{Min: "-inf", Max: "+inf"})
+	assert.Equal(t, []store.ZMember{{Member: "c", Score: 17.5}}, members, "Invalid members after removing range")
+
+	s.ZRem("board", "c")
+	n, _ = s.ZCard("board")
+	assert.Equal(t, 0, n, "Removing every member should delete the set")
+
+	s.Set("string", "abc")
+	_, err = s.ZAdd("string", store.ZAddOptions{}, store.ZMember{Member: "a", Score: 1})
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Adding to a string should be wrong type")
+	_, err = s.ZScore("string", "a")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Score of a string should be wrong type")
+
+	_, err = s.ZAdd("board", store.ZAddOptions{}, store.ZMember{Member: "a", Score: math.NaN()})
+	assert.True(t, errors.Is(err, store.ErrNotFloat), "NaN score should fail")
+}
+
+func testSortedSetAddOptions(t *testing.T, s store.Store) {
+	s.ZAdd("key", store.ZAddOptions{}, store.ZMember{Member: "a", Score: 10})
+
+	n, _ := s.ZAdd("key", store.ZAddOptions{NX: true}, store.ZMember{Member: "a", Score: 1}, store.ZMember{Member: "b", Score: 1})
+	assert.Equal(t, 1, n, "NX should add new members")
+	score, _ := s.ZScore("key", "a")
+	assert.Equal(t, 10.0, score, "NX should not update existing members")
+
+	n, _ = s.ZAdd("key", store.ZAddOptions{XX: true}, store.ZMember{Member: "a", Score: 5}, store.ZMember{Member: "c", Score: 1})
+	assert.Equal(t, 0, n, "XX should not add new members")
+	score, _ = s.ZScore("key", "a")
+	assert.Equal(t, 5.0, score, "XX should update existing members")
+	_, err := s.ZScore("key", "c")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "XX should not add new members")
+
+	s.ZAdd("key", store.ZAddOptions{GT: true}, store.ZMember{Member: "a", Score: 3}, store.ZMember{Member: "b", Score: 8})
+	score, _ = s.ZScore("key", "a")
+	assert.Equal(t, 5.0, score, "GT should not lower scores")
+	score, _ = s.ZScore("key", "b")
+	assert.Equal(t, 8.0, score, "GT should raise scores")
+
+	n, _ = s.ZAdd("key", store.ZAddOptions{LT: true}, store.ZMember{Member: "a", Score: 2}, store.ZMember{Member: "b", Score: 9}, store.ZMember{Member: "d", Score: 1})
+	assert.Equal(t, 1, n, "LT should add new members")
+	score, _ = s.ZScore("key", "a")
+	assert.Equal(t, 2.0, score, "LT should lower scores")
+	score, _ = s.ZScore("key", "b")
+	assert.Equal(t, 8.0, score, "LT should not raise scores")
+
+	_, err = s.ZAdd("key", store.ZAddOptions{NX: true, XX: true}, store.ZMember{Member: "a", Score: 1})
+	assert.True(t, errors.Is(err, store.ErrZAddOptions), "NX and XX should not be combined")
+	_, err = s.ZAdd("key", store.ZAddOptions{NX: true, GT: true}, store.ZMember{Member: "a", Score: 1})
+	assert.True(t, errors.Is(err, store.ErrZAddOptions), "NX and GT should not be combined")
+	_, err = s.ZAdd("key", store.ZAddOptions{GT: true, LT: true}, store.ZMember{Member: "a", Score: 1})
+	assert.True(t, errors.Is(err, store.ErrZAddOptions), "GT and LT should not be combined")
+
+	err = s.Transaction([]string{"board"}, func(tx store.Tx) error {
+		err := tx.ZAdd("board", store.ZAddOptions{NX: true, XX: true}, store.ZMember{Member: "a", Score: 1})
+		assert.True(t, errors.Is(err, store.ErrZAddOptions), "NX and XX should be refused before the commit")
+		if _, err := tx.ZScore("board", "a"); !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		tx.ZAdd("board", store.ZAddOptions{}, store.ZMember{Member: "a", Score: 1}, store.ZMember{Member: "b", Score: 2})
+		tx.ZIncrBy("board", "a", 5)
+		return tx.ZRem("board", "b")
+	})
+	assert.Nil(t, err, "Error updating a sorted set in a transaction %v", err)
+	score, _ = s.ZScore("board", "a")
+	assert.Equal(t, 6.0, score, "Invalid score after transaction")
+	_, err = s.ZScore("board", "b")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Removed member should not be found")
+}
+
+func testSortedSetRange(t *testing.T, s store.Store) {
+	for i := 0; i < 20; i++ {
+		s.ZAdd("events", store.ZAddOptions{}, store.ZMember{Member: fmt.Sprintf("e%02d", i), Score: float64(i)})
+	}
+
+	members, err := s.ZRangeByScore("events", store.ZRangeBy{Min: "5", Max: "(8"})
+	assert.Nil(t, err, "Error fetching range %v", err)
+	assert.Equal(t, []store.ZMember{{Member: "e05", Score: 5}, {Member: "e06", Score: 6}, {Member: "e07", Score: 7}}, members, "Invalid score range")
+
+	members, err = s.ZRangeByScore("events", store.ZRangeBy{Min: "-inf", Max: "+inf", Offset: 10, Count: 2})
+	assert.Nil(t, err, "Error fetching limited range %v", err)
+	assert.Equal(t, []store.ZMember{{Member: "e10", Score: 10}, {Member: "e11", Score: 11}}, members, "Invalid limited range")
+
+	members, _ = s.ZRangeByScore("events", store.ZRangeBy{Min: "(17", Max: "+inf", Offset: 1})
+	assert.Equal(t, []store.ZMember{{Member: "e19", Score: 19}}, members, "Offset without count should return the rest of the range")
+
+	members, err = s.ZRangeByScore("events", store.ZRangeBy{Min: "30", Max: "40"})
+	assert.Nil(t, err, "Error fetching empty range %v", err)
+	assert.Empty(t, members, "Range past the highest score should be empty")
+
+	members, _ = s.ZRangeByScore("missing", store.ZRangeBy{Min: "-inf", Max: "+inf"})
+	assert.Empty(t, members, "Range of a missing set should be empty")
+
+	_, err = s.ZRangeByScore("events", store.ZRangeBy{Min: "abc", Max: "+inf"})
+	assert.True(t, errors.Is(err, store.ErrScoreRange), "Invalid score bound should fail")
+
+	for _, m := range []string{"apple", "banana", "cherry", "date", "fig"} {
+		s.ZAdd("words", store.ZAddOptions{}, store.ZMember{Member: m})
+	}
+
+	words, err := s.ZRangeByLex("words", store.ZRangeBy{Min: "[b", Max: "(date"})
+	assert.Nil(t, err, "Error fetching lex range %v", err)
+	assert.Equal(t, []string{"banana", "cherry"}, words, "Invalid lex range")
+
+	words, _ = s.ZRangeByLex("words", store.ZRangeBy{Min: "-", Max: "+", Offset: 1, Count: 3})
+	assert.Equal(t, []string{"banana", "cherry", "date"}, words, "Invalid limited lex range")
+
+	words, _ = s.ZRangeByLex("words", store.ZRangeBy{Min: "(cherry", Max: "+"})
+	assert.Equal(t, []string{"date", "fig"}, words, "Invalid open lex range")
+
+	_, err = s.ZRangeByLex("words", store.ZRangeBy{Min: "b", Max: "+"})
+	assert.True(t, errors.Is(err, store.ErrLexRange), "Lex bound without a prefix should fail")
+}
+
+func testKeys(t *testing.T, s store.Store) {
+	s.Set("a", "1")
+	s.Set("b", "2")
+	s.Set("c", "3")
+
+	n, err := s.Exists("a", "b", "missing", "a")
+	assert.Nil(t, err, "Error checking keys %v", err)
+	assert.Equal(t, 3, n, "Keys given twice should be counted twice")
+	n, err = s.Touch("a", "missing")
+	assert.Nil(t, err, "Error touching keys %v", err)
+	assert.Equal(t, 1, n, "Invalid number of touched keys")
+
+	n, err = s.DeleteKey("a", "b", "missing")
+	assert.Nil(t, err, "Error deleting keys %v", err)
+	assert.Equal(t, 2, n, "Invalid number of deleted keys")
+	n, _ = s.Exists("a", "b")
+	assert.Equal(t, 0, n, "Deleted keys should not exist")
+	n, err = s.Unlink("c", "missing")
+	assert.Nil(t, err, "Error unlinking keys %v", err)
+	assert.Equal(t, 1, n, "Invalid number of unlinked keys")
+
+	s.SetWithTTL("old", "value", time.Minute)
+	s.Set("new", "other")
+	assert.Nil(t, s.Rename("old", "new"), "Error renaming key")
+	v, _ := s.GetString("new")
+	assert.Equal(t, "value", v, "Renaming should replace the value of the new key")
+	ttl, _ := s.TTL("new")
+	assert.True(t, ttl > 0 && ttl <= time.Minute, "Renaming should keep the expiry, got %v", ttl)
+	_, err = s.GetString("old")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Renamed key should not be found")
+	err = s.Rename("old", "new")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Renaming a missing key should not be found")
+
+	s.Set("other", "x")
+	ok, err := s.RenameIfNotExists("new", "other")
+	assert.Nil(t, err, "Error renaming key %v", err)
+	assert.False(t, ok, "Key should not be renamed onto an existing key")
+	ok, err = s.RenameIfNotExists("new", "renamed")
+	assert.Nil(t, err, "Error renaming key %v", err)
+	assert.True(t, ok, "Key should be renamed")
+	v, _ = s.GetString("renamed")
+	assert.Equal(t, "value", v, "Invalid value of renamed key")
+	_, err = s.RenameIfNotExists("new", "renamed")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Renaming a missing key should not be found")
+}
+
+func testKeyType(t *testing.T, s store.Store) {
+	s.Set("string", "abc")
+	s.SetHash("hash", "a", 1)
+	s.PushItemToList("list", "a", true)
+	s.SetAdd("set", "a")
+	s.ZAdd("zset", store.ZAddOptions{}, store.ZMember{Member: "a", Score: 1})
+
+	types := []struct {
+		key  string
+		want store.KeyType
+		name string
+	}{
+		{"string", store.KeyTypeString, "string"},
+		{"hash", store.KeyTypeHash, "hash"},
+		{"list", store.KeyTypeList, "list"},
+		{"set", store.KeyTypeSet, "set"},
+		{"zset", store.KeyTypeZSet, "zset"},
+		{"missing", store.KeyTypeNone, "none"},
+	}
+	for _, tt := range types {
+		kt, err := s.Type(tt.key)
+		assert.Nil(t, err, "Error fetching type of %s %v", tt.key, err)
+		assert.Equal(t, tt.want, kt, "Invalid type of %s", tt.key)
+		assert.Equal(t, tt.name, kt.String(), "Invalid type name of %s", tt.key)
+	}
+}
+
+func testCopy(t *testing.T, s store.Store) {
+	s.HMSet("src", map[string]interface{}{"a": 1, "b": 2})
+	s.Expire("src", time.Minute)
+
+	ok, err := s.Copy("src", "dst", false)
+	assert.Nil(t, err, "Error copying key %v", err)
+	assert.True(t, ok, "Key should be copied")
+	all, _ := s.HGetAll("dst")
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, all, "Invalid copied hash")
+	ttl, _ := s.TTL("dst")
+	assert.True(t, ttl > 0 && ttl <= time.Minute, "Copy should keep the expiry, got %v", ttl)
+
+	s.SetHash("dst", "c", 3)
+	all, _ = s.HGetAll("src")
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, all, "Changing the copy should not change the source")
+
+	s.Set("string", "abc")
+	ok, err = s.Copy("string", "dst", false)
+	assert.Nil(t, err, "Error copying key %v", err)
+	assert.False(t, ok, "Existing key should not be replaced")
+	ok, err = s.Copy("string", "dst", true)
+	assert.Nil(t, err, "Error copying key %v", err)
+	assert.True(t, ok, "Existing key should be replaced")
+	v, _ := s.GetString("dst")
+	assert.Equal(t, "abc", v, "Invalid replaced value")
+
+	ok, err = s.Copy("missing", "dst", true)
+	assert.Nil(t, err, "Error copying missing key %v", err)
+	assert.False(t, ok, "Missing key should not be copied")
+	_, err = s.Copy("src", "src", true)
+	assert.True(t, errors.Is(err, store.ErrSameKey), "Copying a key onto itself should fail")
+
+	s.Set("config", "v2")
+	err = s.Transaction([]string{"config"}, func(tx store.Tx) error {
+		n, err := tx.Exists("config", "config:old")
+		if err != nil || n != 1 {
+			return err
+		}
+		tx.Copy("config", "config:backup", false)
+		tx.RenameIfNotExists("config", "config:old")
+		return tx.Rename("config:backup", "config:current")
+	})
+	assert.Nil(t, err, "Error copying and renaming in a transaction %v", err)
+	n, _ := s.Exists("config")
+	assert.Equal(t, 0, n, "Renamed key should not exist")
+	v, _ = s.GetString("config:old")
+	assert.Equal(t, "v2", v, "Invalid renamed value")
+	v, _ = s.GetString("config:current")
+	assert.Equal(t, "v2", v, "Invalid copied and renamed value")
+}
+
+func testClearDataStore(t *testing.T, s store.Store) {
+	assert.Nil(t, s.Set("key", "value"))
+	assert.Nil(t, s.SetAdd("set", "value"))
+
+	err := s.ClearDataStore()
+	assert.Nil(t, err, "Error clearing the store %v", err)
+
+	_, err = s.GetString("key")
+	assert.NotNil(t, err, "Key should not exist after clearing the store")
+
+	members, err := s.GetSetStringMembers("set")
+	assert.Nil(t, err, "Error getting set members")
+	assert.Equal(t, 0, len(members), "Set should be empty after clearing the store")
+}
+
+func testDeleteByPattern(t *testing.T, s store.Store) {
+	for i := 0; i < 250; i++ {
+		s.Set(fmt.Sprintf("session:%d", i), i)
+	}
+	s.SetAdd("session:set", "a")
+	s.Set("user:1", "value")
+
+	n, err := s.DeleteByPattern("session:*")
+	assert.Nil(t, err, "Error deleting by pattern %v", err)
+	assert.Equal(t, 251, n, "Invalid number of deleted keys")
+
+	keys := map[string]bool{}
+	it := s.Scan("", 0)
+	for it.Next() {
+		keys[it.Val()] = true
+	}
+	assert.Equal(t, map[string]bool{"user:1": true}, keys, "Only keys not matching the pattern should be left")
+
+	n, err = s.DeleteByPattern("session:*")
+	assert.Nil(t, err, "Error deleting by pattern without matches %v", err)
+	assert.Equal(t, 0, n, "Nothing should be deleted without matches")
+
+	_, err = s.DeleteByPattern("")
+	assert.True(t, errors.Is(err, store.ErrEmptyPattern), "Empty pattern should be refused")
+	_, err = s.GetString("user:1")
+	assert.Nil(t, err, "Empty pattern should not delete keys %v", err)
+}
+
+func testErrNotFound(t *testing.T, s store.Store) {
+	_, err := s.GetString("key")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing string should be not found, got %v", err)
+
+	var opErr *store.OpError
+	assert.True(t, errors.As(err, &opErr), "Error should be an OpError, got %T", err)
+	if opErr != nil {
+		assert.Equal(t, "GetString", opErr.Op, "Invalid operation")
+		assert.Equal(t, "key", opErr.Key, "Invalid key")
+	}
+
+	_, err = s.GetInt64("key")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing int64 should be not found, got %v", err)
+
+	_, err = s.GetHashString("key", "field")
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Missing hash field should be not found, got %v", err)
+
+	_, err = s.PopItemFromList("key", store.DataTypeString, true)
+	assert.True(t, errors.Is(err, store.ErrNotFound), "Popping an empty list should be not found, got %v", err)
+}
+
+func testErrWrongType(t *testing.T, s store.Store) {
+	pushAll(t, s, "key", "a")
+
+	_, err := s.GetString("key")
+	assert.True(t, errors.Is(err, store.ErrWrongType), "Fetching a list as a string should be wrong type, got %v", err)
+	assert.True(t, errors.Is(s.SetHash("key", "field", "v"), store.ErrWrongType), "Setting a hash field on a list should be wrong type")
+	assert.True(t, errors.Is(s.SetAdd("key", "v"), store.ErrWrongType), "Adding to a set on a list should be wrong type")
+}
@@ -0,0 +1,777 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//DefaultTxRetries is the number of times a transaction is retried after a conflict when the store doesn't set its own
+//limit.
+const DefaultTxRetries = 10
+
+//Tx is the transaction passed to the function run by Transaction. Reads run straight away and see the current values
+//of the keys. Writes are queued and applied atomically once the function returns nil, so they always return nil.
+type Tx interface {
+	GetString(key string) (string, error)
+	GetInt64(key string) (int64, error)
+	GetHashString(key string, hash string) (string, error)
+	SetIsMember(key string, value interface{}) (bool, error)
+	LengthOfList(key string) (int, error)
+	ItemsFromList(key string, dataType int, start, end int) (interface{}, error)
+	ItemFromList(key string, dataType int, index int) (interface{}, error)
+	ZScore(key string, member string) (float64, error)
+	HashExists(key string, hash string) (bool, error)
+	Exists(keys ...string) (int, error)
+
+	DeleteKey(keys ...string) error
+	Set(key string, value interface{}) error
+	SetHash(key string, hash string, value interface{}) error
+	DeleteHash(key string, hash string) error
+	SetExpiry(key string, seconds int) error
+	Increment(key string) error
+	Decrement(key string) error
+	SetAdd(key string, value interface{}) error
+	SetRemove(key string, value interface{}) error
+	PushItemToList(key string, value interface{}, atEnd bool) error
+	RemoveItemFromList(key string, count int, value interface{}) error
+	PopItemFromList(key string, atEnd bool) error
+	HMSet(key string, values map[string]interface{}) error
+	Expire(key string, ttl time.Duration) error
+	ExpireAt(key string, t time.Time) error
+	Persist(key string) error
+	SetWithTTL(key string, value interface{}, ttl time.Duration) error
+	IncrementBy(key string, n int64) error
+	DecrementBy(key string, n int64) error
+	IncrementByFloat(key string, n float64) error
+	HashIncrementBy(key string, hash string, n int64) error
+	SetItemInList(key string, index int, value interface{}) error
+	TrimList(key string, start, end int) error
+	InsertItemInList(key string, pivot, value interface{}, before bool) error
+	MoveItemToList(src, dst string) error
+	SetUnionStore(dst string, keys ...string) error
+	SetIntersectStore(dst string, keys ...string) error
+	SetDiffStore(dst string, keys ...string) error
+	ZAdd(key string, opts ZAddOptions, members ...ZMember) error
+	ZIncrBy(key string, member string, n float64) error
+	ZRem(key string, members ...string) error
+	HashSetIfNotExists(key string, hash string, value interface{}) error
+	HashDelete(key string, hashes ...string) error
+	Rename(key, newKey string) error
+	RenameIfNotExists(key, newKey string) error
+	Copy(src, dst string, replace bool) error
+}
+
+//tx implements Tx, running reads with read and queueing writes.
+type tx struct {
+	read func(cmd command) (interface{}, error)
+	cmds []command
+}
+
+//queue queues a write.
+func (t *tx) queue(cmd command) error {
+	t.cmds = append(t.cmds, cmd)
+	return nil
+}
+
+//GetString retrieves the string data stored at key.
+func (t *tx) GetString(key string) (string, error) {
+	v, err := t.read(getStringCommand(key))
+	s, _ := v.(string)
+	return s, err
+}
+
+//GetInt64 retrieves the int64 data stored at key.
+func (t *tx) GetInt64(key string) (int64, error) {
+	v, err := t.read(getInt64Command(key))
+	n, _ := v.(int64)
+	return n, err
+}
+
+//GetHashString returns the string value of the hash.
+func (t *tx) GetHashString(key string, hash string) (string, error) {
+	v, err := t.read(getHashStringCommand(key, hash))
+	s, _ := v.(string)
+	return s, err
+}
+
+//SetIsMember returns true if the value is a member of the set.
+func (t *tx) SetIsMember(key string, value interface{}) (bool, error) {
+	v, err := t.read(setIsMemberCommand(key, value))
+	b, _ := v.(bool)
+	return b, err
+}
+
+//LengthOfList returns the length of the list.
+func (t *tx) LengthOfList(key string) (int, error) {
+	v, err := t.read(lengthOfListCommand(key))
+	n, _ := v.(int)
+	return n, err
+}
+
+//ItemsFromList returns the items of the list from start to end as a slice of the data type.
+func (t *tx) ItemsFromList(key string, dataType int, start, end int) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("ItemsFromList", key, ErrInvalidDataType)
+	}
+	return t.read(itemsFromListCommand(key, dataType, start, end))
+}
+
+//ItemFromList returns the item at the index of the list converted to the data type.
+func (t *tx) ItemFromList(key string, dataType int, index int) (interface{}, error) {
+	if !validDataType(dataType) {
+		return nil, opError("ItemFromList", key, ErrInvalidDataType)
+	}
+	return t.read(itemFromListCommand(key, dataType, index))
+}
+
+//ZScore returns the score of the member.
+func (t *tx) ZScore(key string, member string) (float64, error) {
+	v, err := t.read(zscoreCommand(key, member))
+	f, _ := v.(float64)
+	return f, err
+}
+
+//HashExists returns true if the hash key exists.
+func (t *tx) HashExists(key string, hash string) (bool, error) {
+	v, err := t.read(hashExistsCommand(key, hash))
+	b, _ := v.(bool)
+	return b, err
+}
+
+//Exists returns the number of the keys that exist.
+func (t *tx) Exists(keys ...string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	v, err := t.read(existsCommand(keys))
+	n, _ := v.(int)
+	return n, err
+}
+
+//DeleteKey queues deleting the keys.
+func (t *tx) DeleteKey(keys ...string) error {
+	return t.queue(deleteKeyCommand(keys))
+}
+
+//Set queues setting the value for the key.
+func (t *tx) Set(key string, value interface{}) error {
+	return t.queue(setCommand(key, value))
+}
+
+//SetHash queues setting the value of the hash key.
+func (t *tx) SetHash(key string, hash string, value interface{}) error {
+	return t.queue(setHashCommand(key, hash, value))
+}
+
+//DeleteHash queues deleting the hash key.
+func (t *tx) DeleteHash(key string, hash string) error {
+	return t.queue(deleteHashCommand(key, hash))
+}
+
+//SetExpiry queues setting the expiry of the key.
+func (t *tx) SetExpiry(key string, seconds int) error {
+	return t.queue(setExpiryCommand(key, seconds))
+}
+
+//Increment queues incrementing the value of key by 1.
+func (t *tx) Increment(key string) error {
+	return t.queue(incrementCommand(key))
+}
+
+//Decrement queues decrementing the value of key by 1.
+func (t *tx) Decrement(key string) error {
+	return t.queue(decrementCommand(key))
+}
+
+//SetAdd queues adding the value to a set.
+func (t *tx) SetAdd(key string, value interface{}) error {
+	return t.queue(setAddCommand(key, value))
+}
+
+//SetRemove queues removing the value from a set.
+func (t *tx) SetRemove(key string, value interface{}) error {
+	return t.queue(setRemoveCommand(key, value))
+}
+
+//PushItemToList queues pushing an item to the front or the end of the list.
+func (t *tx) PushItemToList(key string, value interface{}, atEnd bool) error {
+	return t.queue(pushItemToListCommand(key, value, atEnd))
+}
+
+//RemoveItemFromList queues removing count occurrences of the item from the list.
+func (t *tx) RemoveItemFromList(key string, count int, value interface{}) error {
+	return t.queue(removeItemFromListCommand(key, count, value))
+}
+
+//PopItemFromList queues popping an item from the front or the back of the list. Use ItemsFromList to read the item
+//first, as queued writes don't return values.
+func (t *tx) PopItemFromList(key string, atEnd bool) error {
+	return t.queue(popItemFromListCommand(key, atEnd))
+}
+
+//HMSet queues setting the values of the hash keys.
+func (t *tx) HMSet(key string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return t.queue(hmsetCommand(key, values))
+}
+
+//Expire queues setting the expiry of the key with millisecond precision.
+func (t *tx) Expire(key string, ttl time.Duration) error {
+	return t.queue(expireCommand(key, ttl))
+}
+
+//ExpireAt queues setting the key to expire at the time.
+func (t *tx) ExpireAt(key string, at time.Time) error {
+	return t.queue(expireAtCommand(key, at))
+}
+
+//Persist queues removing the expiry of the key.
+func (t *tx) Persist(key string) error {
+	return t.queue(persistCommand(key))
+}
+
+//SetWithTTL queues setting the value for the key together with its expiry. ErrInvalidTTL is returned straight away
+//when the TTL isn't positive.
+func (t *tx) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		return opError("SetWithTTL", key, ErrInvalidTTL)
+	}
+	return t.queue(setWithTTLCommand(key, value, ttl))
+}
+
+//IncrementBy queues adding n to the integer stored at key.
+func (t *tx) IncrementBy(key string, n int64) error {
+	return t.queue(incrementByCommand(key, n))
+}
+
+//DecrementBy queues subtracting n from the integer stored at key.
+func (t *tx) DecrementBy(key string, n int64) error {
+	return t.queue(decrementByCommand(key, n))
+}
+
+//IncrementByFloat queues adding n to the number stored at key.
+func (t *tx) IncrementByFloat(key string, n float64) error {
+	return t.queue(incrementByFloatCommand(key, n))
+}
+
+//HashIncrementBy queues adding n to the integer stored in the hash key.
+func (t *tx) HashIncrementBy(key string, hash string, n int64) error {
+	return t.queue(hashIncrementByCommand(key, hash, n))
+}
+
+//SetItemInList queues replacing the item at the index of the list.
+func (t *tx) SetItemInList(key string, index int, value interface{}) error {
+	return t.queue(setItemInListCommand(key, index, value))
+}
+
+//TrimList queues trimming the list to the items from start to end.
+func (t *tx) TrimList(key string, start, end int) error {
+	return t.queue(trimListCommand(key, start, end))
+}
+
+//InsertItemInList queues inserting the value before or after the first item of the list equal to pivot.
+func (t *tx) InsertItemInList(key string, pivot, value interface{}, before bool) error {
+	return t.queue(insertItemInListCommand(key, pivot, value, before))
+}
+
+//MoveItemToList queues moving an item from the end of the src list to the front of the dst list. Use ItemFromList to
+//read the item first, as queued writes don't return values.
+func (t *tx) MoveItemToList(src, dst string) error {
+	return t.queue(moveItemToListCommand(src, dst))
+}
+
+//SetUnionStore queues storing the union of the sets in dst. ErrNoKeys is returned straight away when no keys are
+//given.
+func (t *tx) SetUnionStore(dst string, keys ...string) error {
+	if len(keys) == 0 {
+		return opError("SetUnionStore", dst, ErrNoKeys)
+	}
+	return t.queue(setUnionStoreCommand(dst, keys))
+}
+
+//SetIntersectStore queues storing the intersection of the sets in dst. ErrNoKeys is returned straight away when no
+//keys are given.
+func (t *tx) SetIntersectStore(dst string, keys ...string) error {
+	if len(keys) == 0 {
+		return opError("SetIntersectStore", dst, ErrNoKeys)
+	}
+	return t.queue(setIntersectStoreCommand(dst, keys))
+}
+
+//SetDiffStore queues storing the difference of the sets in dst. ErrNoKeys is returned straight away when no keys are
+//given.
+func (t *tx) SetDiffStore(dst string, keys ...string) error {
+	if len(keys) == 0 {
+		return opError("SetDiffStore", dst, ErrNoKeys)
+	}
+	return t.queue(setDiffStoreCommand(dst, keys))
+}
+
+//ZAdd queues adding the members to the sorted set or updating their scores, subject to the options. ErrZAddOptions is
+//returned straight away when the options can't be combined.
+func (t *tx) ZAdd(key string, opts ZAddOptions, members ...ZMember) error {
+	if !opts.valid() {
+		return opError("ZAdd", key, ErrZAddOptions)
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	return t.queue(zaddCommand(key, opts, members))
+}
+
+//ZIncrBy queues adding n to the score of the member.
+func (t *tx) ZIncrBy(key string, member string, n float64) error {
+	return t.queue(zincrByCommand(key, member, n))
+}
+
+//ZRem queues removing the members from the sorted set.
+func (t *tx) ZRem(key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return t.queue(zremCommand(key, members))
+}
+
+//HashSetIfNotExists queues setting the value of the hash key only if it doesn't exist.
+func (t *tx) HashSetIfNotExists(key string, hash string, value interface{}) error {
+	return t.queue(hashSetIfNotExistsCommand(key, hash, value))
+}
+
+//HashDelete queues deleting the hash keys.
+func (t *tx) HashDelete(key string, hashes ...string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	return t.queue(hashDeleteCommand(key, hashes))
+}
+
+//Rename queues renaming the key to newKey, replacing any value stored at newKey.
+func (t *tx) Rename(key, newKey string) error {
+	return t.queue(renameCommand(key, newKey))
+}
+
+//RenameIfNotExists queues renaming the key to newKey only if newKey doesn't exist.
+func (t *tx) RenameIfNotExists(key, newKey string) error {
+	return t.queue(renameIfNotExistsCommand(key, newKey))
+}
+
+//Copy queues copying the value stored at src along with its expiry to dst.
+func (t *tx) Copy(src, dst string, replace bool) error {
+	return t.queue(copyCommand(src, dst, replace))
+}
+
+//txRetries returns the number of retries, falling back to DefaultTxRetries.
+func txRetries(n int) int {
+	if n <= 0 {
+		return DefaultTxRetries
+	}
+	return n
+}
+
+//Transaction runs fn and applies the writes it queues atomically with MULTI/EXEC. The watched keys are watched before
+//fn runs and the transaction is retried when any of them change before EXEC. ErrTxConflict is returned once the
+//retries are used up. An error returned by fn aborts the transaction and is returned as is.
+func (r *Redis) Transaction(watchKeys []string, fn func(tx Tx) error) error {
+	return r.TransactionContext(context.Background(), watchKeys, fn)
+}
+
+//TransactionContext runs fn and applies the writes it queues atomically with MULTI/EXEC.
+func (r *Redis) TransactionContext(ctx context.Context, watchKeys []string, fn func(tx Tx) error) error {
+	for i := 0; i <= txRetries(r.TxRetries); i++ {
+		committed, err := r.transaction(ctx, watchKeys, fn)
+		if err != nil || committed {
+			return err
+		}
+	}
+	return opError("Transaction", "", ErrTxConflict)
+}
+
+//transaction makes a single attempt at running the transaction. It returns false if a watched key changed.
+func (r *Redis) transaction(ctx context.Context, watchKeys []string, fn func(tx Tx) error) (bool, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return false, opError("Transaction", "", err)
+	}
+	//Closing the pooled connection unwatches the keys and discards an unfinished MULTI.
+	defer conn.Close()
+
+	if len(watchKeys) > 0 {
+		if _, err := conn.Do("WATCH", append(redis.Args{}.AddFlat(watchKeys), contextArg{ctx})...); err != nil {
+			return false, redisError("Transaction", "", err)
+		}
+	}
+
+	t := &tx{
+		read: func(cmd command) (interface{}, error) {
+			args := append(append([]interface{}{}, cmd.args...), contextArg{ctx})
+			v, err := cmd.reply(conn.Do(cmd.name, args...))
+			return v, redisError(cmd.op, cmd.key, err)
+		},
+	}
+	if err := fn(t); err != nil {
+		return false, err
+	}
+	if len(t.cmds) == 0 {
+		return true, nil
+	}
+
+	if err := conn.Send("MULTI"); err != nil {
+		return false, redisError("Transaction", "", err)
+	}
+	for _, cmd := range t.cmds {
+		if err := conn.Send(cmd.name, cmd.args...); err != nil {
+			return false, redisError("Transaction", "", err)
+		}
+	}
+
+	replies, err := redis.Values(conn.Do("EXEC", contextArg{ctx}))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, redisError("Transaction", "", err)
+	}
+
+	for i, cmd := range t.cmds {
+		if re, ok := replies[i].(redis.Error); ok {
+			return true, redisError(cmd.op, cmd.key, re)
+		}
+	}
+	return true, nil
+}
+
+//lockedMemoryStore runs commands on a memory store whose lock is already held.
+type lockedMemoryStore struct {
+	m *MemoryStore
+}
+
+//DeleteKey deletes the keys and returns the number of keys deleted.
+func (l lockedMemoryStore) DeleteKey(keys ...string) (int, error) {
+	return l.m.deleteKeyLocked(keys...)
+}
+
+//GetString retrieves the string data stored at key.
+func (l lockedMemoryStore) GetString(key string) (string, error) {
+	return l.m.getStringLocked(key)
+}
+
+//GetInt64 retrieves the int64 data stored at key.
+func (l lockedMemoryStore) GetInt64(key string) (int64, error) {
+	return l.m.getInt64Locked(key)
+}
+
+//Set sets the value for the key.
+func (l lockedMemoryStore) Set(key string, value interface{}) error {
+	return l.m.setLocked(key, value)
+}
+
+//SetHash sets the value of the hash key.
+func (l lockedMemoryStore) SetHash(key string, hash string, value interface{}) error {
+	return l.m.setHashLocked(key, hash, value)
+}
+
+//DeleteHash deletes the hash key.
+func (l lockedMemoryStore) DeleteHash(key string, hash string) error {
+	return l.m.deleteHashLocked(key, hash)
+}
+
+//GetHashString returns the string value of the hash key.
+func (l lockedMemoryStore) GetHashString(key string, hash string) (string, error) {
+	return l.m.getHashStringLocked(key, hash)
+}
+
+//SetExpiry sets the expiry of the key.
+func (l lockedMemoryStore) SetExpiry(key string, seconds int) error {
+	return l.m.setExpiryLocked(key, seconds)
+}
+
+//Increment increments the value of key by 1.
+func (l lockedMemoryStore) Increment(key string) error {
+	return l.m.incrementLocked(key)
+}
+
+//Decrement decrements the value of key by 1.
+func (l lockedMemoryStore) Decrement(key string) error {
+	return l.m.decrementLocked(key)
+}
+
+//SetAdd adds the value to a set.
+func (l lockedMemoryStore) SetAdd(key string, value interface{}) error {
+	return l.m.setAddLocked(key, value)
+}
+
+//SetRemove removes the value from a set.
+func (l lockedMemoryStore) SetRemove(key string, value interface{}) error {
+	return l.m.setRemoveLocked(key, value)
+}
+
+//SetIsMember returns true if the value is a member of the set.
+func (l lockedMemoryStore) SetIsMember(key string, value interface{}) (bool, error) {
+	return l.m.setIsMemberLocked(key, value)
+}
+
+//PushItemToList pushes an item to the front or the end of the list.
+func (l lockedMemoryStore) PushItemToList(key string, value interface{}, atEnd bool) error {
+	return l.m.pushItemToListLocked(key, value, atEnd)
+}
+
+//RemoveItemFromList removes count occurrences of the item from the list.
+func (l lockedMemoryStore) RemoveItemFromList(key string, count int, value interface{}) error {
+	return l.m.removeItemFromListLocked(key, count, value)
+}
+
+//LengthOfList returns the length of the list.
+func (l lockedMemoryStore) LengthOfList(key string) (int, error) {
+	return l.m.lengthOfListLocked(key)
+}
+
+//PopItemFromList pops an item from the front or the back of the list.
+func (l lockedMemoryStore) PopItemFromList(key string, dataType int, atEnd bool) (interface{}, error) {
+	return l.m.popItemFromListLocked(key, dataType, atEnd)
+}
+
+//ItemsFromList returns the items of the list from start to end.
+func (l lockedMemoryStore) ItemsFromList(key string, dataType int, start, end int) (interface{}, error) {
+	return l.m.itemsFromListLocked(key, dataType, start, end)
+}
+
+//HMSet sets the values of the hash keys.
+func (l lockedMemoryStore) HMSet(key string, values map[string]interface{}) error {
+	return l.m.hmsetLocked(key, values)
+}
+
+//Expire sets the expiry of the key with millisecond precision.
+func (l lockedMemoryStore) Expire(key string, ttl time.Duration) error {
+	return l.m.expireLocked(key, ttl)
+}
+
+//ExpireAt sets the key to expire at the time.
+func (l lockedMemoryStore) ExpireAt(key string, t time.Time) error {
+	return l.m.expireAtLocked(key, t)
+}
+
+//Persist removes the expiry of the key.
+func (l lockedMemoryStore) Persist(key string) error {
+	return l.m.persistLocked(key)
+}
+
+//SetWithTTL sets the value for the key together with its expiry.
+func (l lockedMemoryStore) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	return l.m.setWithTTLLocked(key, value, ttl)
+}
+
+//IncrementBy adds n to the integer stored at key and returns the new value.
+func (l lockedMemoryStore) IncrementBy(key string, n int64) (int64, error) {
+	return l.m.incrementByLocked(key, n)
+}
+
+//DecrementBy subtracts n from the integer stored at key and returns the new value.
+func (l lockedMemoryStore) DecrementBy(key string, n int64) (int64, error) {
+	return l.m.decrementByLocked(key, n)
+}
+
+//IncrementByFloat adds n to the number stored at key and returns the new value.
+func (l lockedMemoryStore) IncrementByFloat(key string, n float64) (float64, error) {
+	return l.m.incrementByFloatLocked(key, n)
+}
+
+//HashIncrementBy adds n to the integer stored in the hash key and returns the new value.
+func (l lockedMemoryStore) HashIncrementBy(key string, hash string, n int64) (int64, error) {
+	return l.m.hashIncrementByLocked(key, hash, n)
+}
+
+//ItemFromList returns the item at the index of the list.
+func (l lockedMemoryStore) ItemFromList(key string, dataType int, index int) (interface{}, error) {
+	return l.m.itemFromListLocked(key, dataType, index)
+}
+
+//SetItemInList replaces the item at the index of the list.
+func (l lockedMemoryStore) SetItemInList(key string, index int, value interface{}) error {
+	return l.m.setItemInListLocked(key, index, value)
+}
+
+//TrimList trims the list to the items from start to end.
+func (l lockedMemoryStore) TrimList(key string, start, end int) error {
+	return l.m.trimListLocked(key, start, end)
+}
+
+//InsertItemInList inserts the value before or after the first item of the list equal to pivot.
+func (l lockedMemoryStore) InsertItemInList(key string, pivot, value interface{}, before bool) (int, error) {
+	return l.m.insertItemInListLocked(key, pivot, value, before)
+}
+
+//MoveItemToList moves an item from the end of the src list to the front of the dst list.
+func (l lockedMemoryStore) MoveItemToList(src, dst string, dataType int) (interface{}, error) {
+	return l.m.moveItemToListLocked(src, dst, dataType)
+}
+
+//SetUnionStore stores the union of the sets in dst.
+func (l lockedMemoryStore) SetUnionStore(dst string, keys ...string) (int, error) {
+	return l.m.setOpStoreLocked("SetUnionStore", dst, keys, unionSets)
+}
+
+//SetIntersectStore stores the intersection of the sets in dst.
+func (l lockedMemoryStore) SetIntersectStore(dst string, keys ...string) (int, error) {
+	return l.m.setOpStoreLocked("SetIntersectStore", dst, keys, intersectSets)
+}
+
+//SetDiffStore stores the difference of the sets in dst.
+func (l lockedMemoryStore) SetDiffStore(dst string, keys ...string) (int, error) {
+	return l.m.setOpStoreLocked("SetDiffStore", dst, keys, diffSets)
+}
+
+//ZAdd adds the members to the sorted set subject to the options.
+func (l lockedMemoryStore) ZAdd(key string, opts ZAddOptions, members ...ZMember) (int, error) {
+	return l.m.zaddLocked(key, opts, members)
+}
+
+//ZIncrBy adds n to the score of the member.
+func (l lockedMemoryStore) ZIncrBy(key string, member string, n float64) (float64, error) {
+	return l.m.zincrByLocked(key, member, n)
+}
+
+//ZScore returns the score of the member.
+func (l lockedMemoryStore) ZScore(key string, member string) (float64, error) {
+	return l.m.zscoreLocked(key, member)
+}
+
+//ZRem removes the members from the sorted set.
+func (l lockedMemoryStore) ZRem(key string, members ...string) (int, error) {
+	return l.m.zremLocked(key, members)
+}
+
+//HashExists returns true if the hash key exists.
+func (l lockedMemoryStore) HashExists(key string, hash string) (bool, error) {
+	return l.m.hashExistsLocked(key, hash)
+}
+
+//HashSetIfNotExists sets the value of the hash key only if it doesn't exist.
+func (l lockedMemoryStore) HashSetIfNotExists(key string, hash string, value interface{}) (bool, error) {
+	return l.m.hashSetIfNotExistsLocked(key, hash, value)
+}
+
+//HashDelete deletes the hash keys.
+func (l lockedMemoryStore) HashDelete(key string, hashes ...string) (int, error) {
+	return l.m.hashDeleteLocked(key, hashes)
+}
+
+//Exists returns the number of the keys that exist.
+func (l lockedMemoryStore) Exists(keys ...string) (int, error) {
+	return l.m.existsLocked(keys)
+}
+
+//Rename renames the key to newKey.
+func (l lockedMemoryStore) Rename(key, newKey string) error {
+	return l.m.renameLocked(key, newKey)
+}
+
+//RenameIfNotExists renames the key to newKey only if newKey doesn't exist.
+func (l lockedMemoryStore) RenameIfNotExists(key, newKey string) (bool, error) {
+	return l.m.renameIfNotExistsLocked(key, newKey)
+}
+
+//Copy copies the value stored at src to dst.
+func (l lockedMemoryStore) Copy(src, dst string, replace bool) (bool, error) {
+	return l.m.copyLocked(src, dst, replace)
+}
+
+//watchedKey is a key watched by running transactions. Its version changes on every write to the key.
+type watchedKey struct {
+	watchers int
+	version  uint64
+}
+
+//watch starts tracking writes to the keys and returns their current versions. The caller must hold the lock.
+func (m *MemoryStore) watch(keys []string) []uint64 {
+	if m.watched == nil {
+		m.watched = make(map[string]*watchedKey)
+	}
+	versions := make([]uint64, len(keys))
+	for i, k := range keys {
+		//Evict an expired entry first, so its expiry doesn't count as a write made after the watch started.
+		m.lookup(k)
+		w, ok := m.watched[k]
+		if !ok {
+			w = &watchedKey{}
+			m.watched[k] = w
+		}
+		w.watchers++
+		versions[i] = w.version
+	}
+	return versions
+}
+
+//unwatch stops tracking writes to the keys once no transaction watches them. The caller must hold the lock.
+func (m *MemoryStore) unwatch(keys []string) {
+	for _, k := range keys {
+		if w, ok := m.watched[k]; ok {
+			if w.watchers--; w.watchers == 0 {
+				delete(m.watched, k)
+			}
+		}
+	}
+}
+
+//Transaction runs fn and applies the writes it queues atomically. Like WATCH on redis, the transaction is retried when
+//any write is made to the watched keys between the start of fn and the commit, even one that leaves the same value.
+//ErrTxConflict is returned once the retries are used up. An error returned by fn aborts the transaction and is
+//returned as is.
+func (m *MemoryStore) Transaction(watchKeys []string, fn func(tx Tx) error) error {
+	for i := 0; i <= txRetries(m.TxRetries); i++ {
+		committed, err := m.attempt(watchKeys, fn)
+		if err != nil || committed {
+			return err
+		}
+	}
+	return opError("Transaction", "", ErrTxConflict)
+}
+
+//attempt runs fn once and commits the writes it queues. It returns false when a watched key was written.
+func (m *MemoryStore) attempt(watchKeys []string, fn func(tx Tx) error) (bool, error) {
+	m.mu.Lock()
+	versions := m.watch(watchKeys)
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.unwatch(watchKeys)
+		m.mu.Unlock()
+	}()
+
+	t := &tx{
+		read: func(cmd command) (interface{}, error) {
+			return cmd.run(m)
+		},
+	}
+	if err := fn(t); err != nil {
+		return false, err
+	}
+	return m.commit(watchKeys, versions, t.cmds)
+}
+
+//commit applies the queued writes unless the version of a watched key changed. The writes run on the locked helpers
+//of the store, so they are applied while the lock is held.
+func (m *MemoryStore) commit(watchKeys []string, versions []uint64, cmds []command) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, k := range watchKeys {
+		//Evict an entry that expired since the watch started, which counts as a write.
+		m.lookup(k)
+		if m.watched[k].version != versions[i] {
+			return false, nil
+		}
+	}
+
+	locked := lockedMemoryStore{m}
+	var err error
+	for _, cmd := range cmds {
+		if _, cmdErr := cmd.run(locked); cmdErr != nil && err == nil {
+			err = cmdErr
+		}
+	}
+	return true, err
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+//parseTime parses a time stored as RFC3339, with or without fractional seconds, or as unix seconds.
+func parseTime(v string) (time.Time, error) {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 or unix seconds", v)
+	}
+	return t, nil
+}
+
+//formatArgs returns args with the times formatted as RFC3339Nano, which parseTime reads back, instead of the fmt form
+//redigo would write. args is copied before it is changed since it may belong to the caller.
+func formatArgs(args []interface{}) []interface{} {
+	formatted := args
+	for i, arg := range args {
+		t, ok := arg.(time.Time)
+		if !ok {
+			continue
+		}
+		if &formatted[0] == &args[0] {
+			formatted = append([]interface{}(nil), args...)
+		}
+		formatted[i] = t.Format(time.RFC3339Nano)
+	}
+	return formatted
+}
+
+//scanValue stores a value in dst the way redis.Scan does. *time.Time destinations are parsed with parseTime as well.
+func scanValue(v []byte, dst interface{}) error {
+	if t, ok := dst.(*time.Time); ok {
+		parsed, err := parseTime(string(v))
+		if err != nil {
+			return err
+		}
+		*t = parsed
+		return nil
+	}
+	_, err := redis.Scan([]interface{}{v}, dst)
+	return err
+}
+
+//GetBytes retrieves the data stored in redis as bytes.
+func (r *Redis) GetBytes(key string) ([]byte, error) {
+	return r.GetBytesContext(context.Background(), key)
+}
+
+//GetBytesContext retrieves the data stored in redis as bytes.
+func (r *Redis) GetBytesContext(ctx context.Context, key string) ([]byte, error) {
+	v, err := redis.Bytes(r.do(ctx, "GET", key))
+	return v, redisError("GetBytes", key, err)
+}
+
+//GetFloat64 retrieves the float64 data stored in redis.
+func (r *Redis) GetFloat64(key string) (float64, error) {
+	return r.GetFloat64Context(context.Background(), key)
+}
+
+//GetFloat64Context retrieves the float64 data stored in redis.
+func (r *Redis) GetFloat64Context(ctx context.Context, key string) (float64, error) {
+	v, err := redis.Float64(r.do(ctx, "GET", key))
+	return v, redisError("GetFloat64", key, err)
+}
+
+//GetBool retrieves the bool data stored in redis. Values stored with Set(key, true) read back as true.
+func (r *Redis) GetBool(key string) (bool, error) {
+	return r.GetBoolContext(context.Background(), key)
+}
+
+//GetBoolContext retrieves the bool data stored in redis.
+func (r *Redis) GetBoolContext(ctx context.Context, key string) (bool, error) {
+	v, err := redis.Bool(r.do(ctx, "GET", key))
+	return v, redisError("GetBool", key, err)
+}
+
+//GetTime retrieves the time stored in redis as RFC3339 or as unix seconds. Times are stored as RFC3339Nano, so values
+//set with Set(key, t) read back as t.
+func (r *Redis) GetTime(key string) (time.Time, error) {
+	return r.GetTimeContext(context.Background(), key)
+}
+
+//GetTimeContext retrieves the time stored in redis as RFC3339 or as unix seconds.
+func (r *Redis) GetTimeContext(ctx context.Context, key string) (time.Time, error) {
+	v, err := redis.String(r.do(ctx, "GET", key))
+	if err != nil {
+		return time.Time{}, redisError("GetTime", key, err)
+	}
+	t, err := parseTime(v)
+	return t, opError("GetTime", key, err)
+}
+
+//Get scans the data stored in redis into dst, which must be a pointer. The destinations supported by redis.Scan are
+//supported, such as *string, *[]byte, *int, *int64, *float64, *bool and redis.Scanner, along with *time.Time.
+func (r *Redis) Get(key string, dst interface{}) error {
+	return r.GetContext(context.Background(), key, dst)
+}
+
+//GetContext scans the data stored in redis into dst, which must be a pointer.
+func (r *Redis) GetContext(ctx context.Context, key string, dst interface{}) error {
+	v, err := redis.Bytes(r.do(ctx, "GET", key))
+	if err != nil {
+		return redisError("Get", key, err)
+	}
+	return opError("Get", key, scanValue(v, dst))
+}
+
+//storedString returns the string stored at key, ErrNotFound if it doesn't exist. The caller must hold the lock.
+func (m *MemoryStore) storedString(key string) (string, error) {
+	s, ok, err := m.stringValue(key)
+	if err == nil && !ok {
+		err = ErrNotFound
+	}
+	return s, err
+}
+
+//GetBytes retrieves the data stored in the memory store as bytes.
+func (m *MemoryStore) GetBytes(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.storedString(key)
+	if err != nil {
+		return nil, opError("GetBytes", key, err)
+	}
+	return []byte(s), nil
+}
+
+//GetFloat64 retrieves the float64 data stored in the memory store.
+func (m *MemoryStore) GetFloat64(key string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.storedString(key)
+	if err != nil {
+		return 0, opError("GetFloat64", key, err)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, opError("GetFloat64", key, err)
+}
+
+//GetBool retrieves the bool data stored in the memory store. Values stored with Set(key, true) read back as true.
+func (m *MemoryStore) GetBool(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.storedString(key)
+	if err != nil {
+		return false, opError("GetBool", key, err)
+	}
+	v, err := strconv.ParseBool(s)
+	return v, opError("GetBool", key, err)
+}
+
+//GetTime retrieves the time stored in the memory store as RFC3339 or as unix seconds.
+func (m *MemoryStore) GetTime(key string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.storedString(key)
+	if err != nil {
+		return time.Time{}, opError("GetTime", key, err)
+	}
+	t, err := parseTime(s)
+	return t, opError("GetTime", key, err)
+}
+
+//Get scans the data stored in the memory store into dst, which must be a pointer. The same destinations as the redis
+//store are supported.
+func (m *MemoryStore) Get(key string, dst interface{}) error {
+	m.mu.Lock()
+	s, err := m.storedString(key)
+	m.mu.Unlock()
+	if err != nil {
+		return opError("Get", key, err)
+	}
+	return opError("Get", key, scanValue([]byte(s), dst))
+}